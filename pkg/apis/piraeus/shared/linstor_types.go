@@ -18,10 +18,20 @@ limitations under the License.
 package shared
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
 
 	lapiconst "github.com/LINBIT/golinstor"
 	lapi "github.com/LINBIT/golinstor/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	"github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
 )
@@ -42,6 +52,107 @@ type SatelliteStatus struct {
 	ConnectionStatus string `json:"connectionStatus"`
 	// StoragePoolStatuses by storage pool name.
 	StoragePoolStatuses []*StoragePoolStatus `json:"storagePoolStatus"`
+	// Properties declared in the spec, with the values currently set on the satellite
+	// +optional
+	Properties map[string]string `json:"properties,omitempty"`
+	// KernelModule reports the state of the DRBD kernel module on the node
+	// +optional
+	KernelModule *KernelModuleStatus `json:"kernelModule,omitempty"`
+	// PreflightChecks are the results of the host checks run before the satellite started
+	// +optional
+	PreflightChecks []*PreflightCheck `json:"preflightChecks,omitempty"`
+	// ResourceHealth aggregates the state of the DRBD resources on the node
+	// +optional
+	ResourceHealth *ResourceHealthStatus `json:"resourceHealth,omitempty"`
+}
+
+// PreflightCheck is the result of a single host check run before the satellite starts.
+type PreflightCheck struct {
+	// Name of the check.
+	Name string `json:"name"`
+	// Result of the check, one of "Pass", "Warn", "Fail" or "Skip".
+	Result PreflightResult `json:"result"`
+	// Message explaining the result.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PreflightResult is the outcome of a preflight check.
+type PreflightResult string
+
+const (
+	// PreflightPass means the host is set up correctly.
+	PreflightPass PreflightResult = "Pass"
+	// PreflightWarn means the host setup may cause problems.
+	PreflightWarn PreflightResult = "Warn"
+	// PreflightFail means the host setup prevents DRBD from working.
+	PreflightFail PreflightResult = "Fail"
+	// PreflightSkip means the check does not apply to the host.
+	PreflightSkip PreflightResult = "Skip"
+)
+
+// PreflightPolicy determines how host preflight checks are run.
+type PreflightPolicy string
+
+const (
+	// PreflightNone disables preflight checks.
+	PreflightNone PreflightPolicy = "None"
+	// PreflightReport reports the results of preflight checks.
+	PreflightReport PreflightPolicy = "Report"
+	// PreflightBlock reports the results of preflight checks, and prevents satellites from starting on failures.
+	PreflightBlock PreflightPolicy = "Block"
+)
+
+// KernelModuleStatus reports the DRBD kernel module loaded on a node.
+type KernelModuleStatus struct {
+	// Version of the loaded DRBD kernel module.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// InjectionMode used to load the kernel module.
+	// +optional
+	InjectionMode KernelModuleInjectionMode `json:"injectionMode,omitempty"`
+	// KernelVersion of the host.
+	// +optional
+	KernelVersion string `json:"kernelVersion,omitempty"`
+	// Error reported by the kernel module injector or by LINSTOR, if DRBD can't be used on the node.
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ResourceHealthStatus aggregates the state of the DRBD resources on a node, as reported by LINSTOR.
+type ResourceHealthStatus struct {
+	// Resources is the number of resources on the node.
+	Resources int32 `json:"resources"`
+	// DiskStates counts the volumes on the node by their DRBD disk state, for example "UpToDate", "Inconsistent",
+	// "Outdated" or "Diskless".
+	// +optional
+	DiskStates map[string]int32 `json:"diskStates,omitempty"`
+	// NoQuorum is the number of resources on the node that are connected to at most half of all replicas,
+	// including the local replica.
+	NoQuorum int32 `json:"noQuorum"`
+	// Syncing is the number of resources on the node receiving data from a peer.
+	Syncing int32 `json:"syncing"`
+	// Degraded is the number of resources on the node that are not fully healthy.
+	Degraded int32 `json:"degraded"`
+	// DegradedResources lists the resources that are not fully healthy, limited to `resourceHealthDetailLimit`
+	// entries.
+	// +optional
+	DegradedResources []*ResourceHealthDetail `json:"degradedResources,omitempty"`
+}
+
+// ResourceHealthDetail reports a resource on a node that is not fully healthy.
+type ResourceHealthDetail struct {
+	// Name of the resource.
+	Name string `json:"name"`
+	// DiskStates of the volumes of the resource on the node, ordered by volume number.
+	// +optional
+	DiskStates []string `json:"diskStates,omitempty"`
+	// NoQuorum is set if the resource has no quorum.
+	// +optional
+	NoQuorum bool `json:"noQuorum,omitempty"`
+	// SyncProgress is the percentage of data already synced, if reported by LINSTOR.
+	// +optional
+	SyncProgress string `json:"syncProgress,omitempty"`
 }
 
 // StoragePoolStatus reports basic information about storage pool state.
@@ -55,8 +166,78 @@ type StoragePoolStatus struct {
 	// Usage reporting
 	FreeCapacity  int64 `json:"freeCapacity"`
 	TotalCapacity int64 `json:"totalCapacity"`
+	// Migration is set if the storage pool no longer matches the spec or was removed from the spec, but can't be
+	// recreated or deleted because it still contains resources.
+	// +optional
+	Migration *StoragePoolMigrationStatus `json:"migration,omitempty"`
+	// Failure is set if the storage pool was created from `devicePaths` and lost access to its devices.
+	// +optional
+	Failure *StoragePoolFailureStatus `json:"failure,omitempty"`
 }
 
+// StoragePoolFailureStatus reports a storage pool created from devices that can no longer be used.
+type StoragePoolFailureStatus struct {
+	// Reason describes the failure, as reported by LINSTOR.
+	Reason string `json:"reason"`
+	// DevicePaths backing the storage pool. To replace a failed or missing device, replace it in the `devicePaths` of
+	// the pool.
+	// +optional
+	DevicePaths []string `json:"devicePaths,omitempty"`
+	// FailedResources have a replica in the storage pool that lost its backing device.
+	// +optional
+	FailedResources []string `json:"failedResources,omitempty"`
+}
+
+// StoragePoolMigrationStatus reports the progress of replacing a storage pool that no longer matches the spec.
+type StoragePoolMigrationStatus struct {
+	// Phase of the migration, either "Pending" or "Evacuating".
+	Phase string `json:"phase"`
+	// Reason describes the difference between the existing pool and the spec.
+	Reason string `json:"reason"`
+	// RemainingResources that still have a replica in the storage pool.
+	// +optional
+	RemainingResources []string `json:"remainingResources,omitempty"`
+}
+
+const (
+	// StoragePoolMigrationPending means the pool waits for the resources to be removed manually.
+	StoragePoolMigrationPending = "Pending"
+	// StoragePoolMigrationEvacuating means the resources are moved to other storage pools by the operator.
+	StoragePoolMigrationEvacuating = "Evacuating"
+)
+
+// StoragePoolMigrationPolicy describes how storage pools that no longer match the spec are replaced.
+type StoragePoolMigrationPolicy string
+
+const (
+	// StoragePoolMigrationManual means pools are only recreated once they no longer contain any resources.
+	StoragePoolMigrationManual StoragePoolMigrationPolicy = "Manual"
+	// StoragePoolMigrationEvacuate means resources are moved to other storage pools before the pool is recreated.
+	StoragePoolMigrationEvacuate StoragePoolMigrationPolicy = "Evacuate"
+)
+
+// StoragePoolRemovalPolicy describes how storage pools that were removed from the spec are deleted.
+type StoragePoolRemovalPolicy string
+
+const (
+	// StoragePoolRemovalManual means pools are only deleted once they no longer contain any resources.
+	StoragePoolRemovalManual StoragePoolRemovalPolicy = "Manual"
+	// StoragePoolRemovalDrain means resources are moved to other storage pools before the pool is deleted.
+	StoragePoolRemovalDrain StoragePoolRemovalPolicy = "Drain"
+)
+
+// DanglingSatellitePolicy describes how satellites without kubernetes node are removed.
+type DanglingSatellitePolicy string
+
+const (
+	// DanglingSatelliteEvict means the satellite is evicted, so LINSTOR moves resources to other nodes.
+	DanglingSatelliteEvict DanglingSatellitePolicy = "Evict"
+	// DanglingSatelliteEvictAndLost means the satellite is evicted and then removed from LINSTOR.
+	DanglingSatelliteEvictAndLost DanglingSatellitePolicy = "EvictAndLost"
+	// DanglingSatelliteReportOnly means the satellite is only reported in the status.
+	DanglingSatelliteReportOnly DanglingSatellitePolicy = "ReportOnly"
+)
+
 // NewStoragePoolStatus convert from golinstor StoragePool to StoragePoolStatus.
 func NewStoragePoolStatus(pool *lapi.StoragePool) *StoragePoolStatus {
 	return &StoragePoolStatus{
@@ -84,6 +265,16 @@ type StoragePools struct {
 	// +optional
 	// +nullable
 	ZFSPools []*StoragePoolZFS `json:"zfsPools"`
+
+	// FilePools for LinstorSatelliteSet to manage
+	// +optional
+	// +nullable
+	FilePools []*StoragePoolFile `json:"filePools"`
+
+	// FileThinPools for LinstorSatelliteSet to manage
+	// +optional
+	// +nullable
+	FileThinPools []*StoragePoolFileThin `json:"fileThinPools"`
 }
 
 func (in *StoragePools) All() []StoragePool {
@@ -100,9 +291,38 @@ func (in *StoragePools) All() []StoragePool {
 		all = append(all, p)
 	}
 
+	for _, p := range in.FilePools {
+		all = append(all, p)
+	}
+
+	for _, p := range in.FileThinPools {
+		all = append(all, p)
+	}
+
 	return all
 }
 
+// AllHostDirectories returns the sorted list of host directories used by file backed storage pools.
+func (in *StoragePools) AllHostDirectories() []string {
+	dirs := make(map[string]struct{})
+	for _, p := range in.FilePools {
+		dirs[p.Directory] = struct{}{}
+	}
+
+	for _, p := range in.FileThinPools {
+		dirs[p.Directory] = struct{}{}
+	}
+
+	result := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		result = append(result, dir)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
 func (in *StoragePools) AllPhysicalStorageCreators() []PhysicalStorageCreator {
 	all := make([]PhysicalStorageCreator, 0)
 	for _, p := range in.LVMPools {
@@ -113,6 +333,10 @@ func (in *StoragePools) AllPhysicalStorageCreators() []PhysicalStorageCreator {
 		all = append(all, p)
 	}
 
+	for _, p := range in.ZFSPools {
+		all = append(all, p)
+	}
+
 	return all
 }
 
@@ -128,15 +352,52 @@ type PhysicalStorageCreator interface {
 	ToPhysicalStorageCreate() lapi.PhysicalStorageCreate
 }
 
+// VolumeGroupExtender is implemented by storage pools that can grow by adding devices to the existing volume group.
+type VolumeGroupExtender interface {
+	PhysicalStorageCreator
+	VolumeGroupExtendCommand(devicePaths []string) ([]string, error)
+}
+
+// StoragePoolRebuilder is implemented by storage pools that can be removed from a node and recreated from their
+// devices, for example to replace a failed device.
+type StoragePoolRebuilder interface {
+	PhysicalStorageCreator
+	GetReplaceDevices() []string
+	DestroyCommand() []string
+}
+
+// destroyScript runs the given command to remove the pool named by the first argument, ignoring errors from a pool
+// that is already (partially) gone. Afterwards, all remaining arguments that are present block devices are wiped.
+func destroyScript(remove string) string {
+	return remove + `; for dev; do if [ -b "$dev" ]; then wipefs --all "$dev" || exit 1; fi; done`
+}
+
+// lvmDestroyScript removes the volume group, dropping any missing physical volumes first.
+var lvmDestroyScript = destroyScript(`vg="$1"; shift; vgreduce --removemissing --force "$vg"; vgremove --force --force --yes "$vg"`)
+
+// zfsDestroyScript removes the zpool.
+var zfsDestroyScript = destroyScript(`zpool="$1"; shift; zpool destroy -f "$zpool"`)
+
 type CommonStoragePoolOptions struct {
 	// Name of the storage pool.
 	Name string `json:"name"`
+
+	// Additional LINSTOR properties to set on the storage pool. Properties required by the storage driver, such as
+	// the volume group name, can not be overridden.
+	// +optional
+	// +nullable
+	Properties map[string]string `json:"properties,omitempty"`
 }
 
 type CommonPhysicalStorageOptions struct {
 	// List of device paths that should make up the VG
 	// +optional
 	DevicePaths []string `json:"devicePaths,omitempty"`
+
+	// Devices in devicePaths that replace failed devices. If one of them is available on a node where the pool
+	// failed, the pool is rebuilt from all devices. Otherwise, new devices only extend the pool.
+	// +optional
+	ReplaceDevices []string `json:"replaceDevices,omitempty"`
 }
 
 // StoragePoolLVM represents LVM storage pool to be managed by a
@@ -182,23 +443,121 @@ type StoragePoolLVMThin struct {
 
 //  StoragePoolZFS represents
 type StoragePoolZFS struct {
-	CommonStoragePoolOptions `json:",inline"`
+	CommonStoragePoolOptions     `json:",inline"`
+	CommonPhysicalStorageOptions `json:",inline"`
 
 	// Name of the zpool to use.
 	ZPool string `json:"zPool"`
 
 	// use thin provisioning
 	Thin bool `json:"thin"`
+
+	// Layout of the vdevs created from `devicePaths`. Defaults to "stripe".
+	// +optional
+	// +kubebuilder:validation:Enum=stripe;mirror;raidz;raidz2;raidz3
+	VDevLayout ZFSVDevLayout `json:"vdevLayout,omitempty"`
+
+	// Number of devices per vdev. Defaults to using all `devicePaths` in a single vdev.
+	// +optional
+	DevicesPerVDev int32 `json:"devicesPerVDev,omitempty"`
+}
+
+// ZFSVDevLayout describes the redundancy of the vdevs that make up a zpool.
+type ZFSVDevLayout string
+
+const (
+	// ZFSVDevStripe stripes data over all devices without redundancy
+	ZFSVDevStripe ZFSVDevLayout = "stripe"
+	// ZFSVDevMirror mirrors data over all devices of a vdev
+	ZFSVDevMirror ZFSVDevLayout = "mirror"
+	// ZFSVDevRaidz uses single parity
+	ZFSVDevRaidz ZFSVDevLayout = "raidz"
+	// ZFSVDevRaidz2 uses double parity
+	ZFSVDevRaidz2 ZFSVDevLayout = "raidz2"
+	// ZFSVDevRaidz3 uses triple parity
+	ZFSVDevRaidz3 ZFSVDevLayout = "raidz3"
+)
+
+// minDevicesPerVDev is the smallest number of devices that make up a vdev of the given layout.
+var minDevicesPerVDev = map[ZFSVDevLayout]int{
+	ZFSVDevMirror: 2,
+	ZFSVDevRaidz:  2,
+	ZFSVDevRaidz2: 3,
+	ZFSVDevRaidz3: 4,
+}
+
+// StoragePoolFile represents a file backed storage pool to be managed by a
+// LinstorSatelliteSet.
+type StoragePoolFile struct {
+	CommonStoragePoolOptions `json:",inline"`
+
+	// Directory on the host in which the backing files are created.
+	Directory string `json:"directory"`
+}
+
+// StoragePoolFileThin represents a file backed storage pool using sparse
+// files to be managed by a LinstorSatelliteSet.
+type StoragePoolFileThin struct {
+	StoragePoolFile `json:",inline"`
 }
 
 func (in *CommonStoragePoolOptions) GetName() string {
 	return in.Name
 }
 
+// withProperties merges the user defined properties with the properties required for the storage pool.
+func (in *CommonStoragePoolOptions) withProperties(required map[string]string) map[string]string {
+	result := make(map[string]string, len(in.Properties)+len(required)+1)
+
+	for k, v := range in.Properties {
+		if _, ok := required[k]; !ok {
+			result[k] = v
+		}
+	}
+
+	if len(result) != 0 {
+		result[spec.LinstorManagedPropertiesProperty] = ManagedPropertiesValue(result)
+	}
+
+	for k, v := range required {
+		result[k] = v
+	}
+
+	return result
+}
+
+// ManagedPropertiesValue returns the value of the marker property that records which properties were set by the
+// operator. This enables removing properties once they are no longer part of the spec, without touching properties
+// set by other means.
+func ManagedPropertiesValue(props map[string]string) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return strings.Join(keys, ",")
+}
+
+// ManagedPropertyKeys returns the property keys recorded in the marker property.
+func ManagedPropertyKeys(props map[string]string) []string {
+	val, ok := props[spec.LinstorManagedPropertiesProperty]
+	if !ok || val == "" {
+		return nil
+	}
+
+	return strings.Split(val, ",")
+}
+
 func (in *CommonPhysicalStorageOptions) GetDevicePaths() []string {
 	return in.DevicePaths
 }
 
+func (in *CommonPhysicalStorageOptions) GetReplaceDevices() []string {
+	return in.ReplaceDevices
+}
+
 func (in *StoragePoolLVM) props() map[string]string {
 	return map[string]string{
 		"StorDriver/LvmVg":               in.VolumeGroup,
@@ -211,7 +570,7 @@ func (in *StoragePoolLVM) ToLinstorStoragePool() lapi.StoragePool {
 	return lapi.StoragePool{
 		StoragePoolName: in.Name,
 		ProviderKind:    lapi.LVM,
-		Props:           in.props(),
+		Props:           in.withProperties(in.props()),
 	}
 }
 
@@ -226,11 +585,25 @@ func (in *StoragePoolLVM) ToPhysicalStorageCreate() lapi.PhysicalStorageCreate {
 		VdoSlabSizeKib:    int64(in.VdoSlabSizeKib),
 		WithStoragePool: lapi.PhysicalStorageStoragePoolCreate{
 			Name:  in.Name,
-			Props: in.props(),
+			Props: in.withProperties(in.props()),
 		},
 	}
 }
 
+// VolumeGroupExtendCommand returns the command that adds `devicePaths` to the volume group of the pool.
+func (in *StoragePoolLVM) VolumeGroupExtendCommand(devicePaths []string) ([]string, error) {
+	if in.VDO {
+		return nil, fmt.Errorf("lvmPool '%s': can't extend volume group on VDO", in.Name)
+	}
+
+	return append([]string{"vgextend", in.VolumeGroup}, devicePaths...), nil
+}
+
+// DestroyCommand returns the command that removes the volume group and wipes all devices of the pool.
+func (in *StoragePoolLVM) DestroyCommand() []string {
+	return append([]string{"sh", "-c", lvmDestroyScript, "lvm-destroy", in.VolumeGroup}, in.DevicePaths...)
+}
+
 func (in *StoragePoolLVMThin) CreatedVolumeGroup() string {
 	if len(in.DevicePaths) != 0 {
 		return fmt.Sprintf("linstor_%s", in.ThinVolume)
@@ -254,7 +627,7 @@ func (in *StoragePoolLVMThin) ToLinstorStoragePool() lapi.StoragePool {
 	return lapi.StoragePool{
 		StoragePoolName: in.Name,
 		ProviderKind:    lapi.LVM_THIN,
-		Props:           in.props(),
+		Props:           in.withProperties(in.props()),
 	}
 }
 
@@ -266,11 +639,33 @@ func (in *StoragePoolLVMThin) ToPhysicalStorageCreate() lapi.PhysicalStorageCrea
 		RaidLevel:    in.RaidLevel,
 		WithStoragePool: lapi.PhysicalStorageStoragePoolCreate{
 			Name:  in.Name,
-			Props: in.props(),
+			Props: in.withProperties(in.props()),
 		},
 	}
 }
 
+// lvmThinExtendScript extends the volume group with all devices passed as arguments, then grows the thin pool to use
+// all free space.
+const lvmThinExtendScript = `vg="$1"; lv="$2"; shift 2; vgextend "$vg" "$@" && lvextend -l +100%FREE "$vg/$lv"`
+
+// VolumeGroupExtendCommand returns the command that adds `devicePaths` to the volume group created for the thin pool,
+// and grows the thin pool to use the new space.
+func (in *StoragePoolLVMThin) VolumeGroupExtendCommand(devicePaths []string) ([]string, error) {
+	if len(in.DevicePaths) == 0 {
+		return nil, fmt.Errorf("lvmThinPool '%s': volume group not created by the operator", in.Name)
+	}
+
+	cmd := []string{"sh", "-c", lvmThinExtendScript, "lvm-thin-extend", in.CreatedVolumeGroup(), in.ThinVolume}
+
+	return append(cmd, devicePaths...), nil
+}
+
+// DestroyCommand returns the command that removes the volume group created for the thin pool and wipes all devices of
+// the pool.
+func (in *StoragePoolLVMThin) DestroyCommand() []string {
+	return append([]string{"sh", "-c", lvmDestroyScript, "lvm-destroy", in.CreatedVolumeGroup()}, in.DevicePaths...)
+}
+
 func (in *StoragePoolZFS) props() map[string]string {
 	return map[string]string{
 		"StorDriver/StorPoolName":        in.ZPool,
@@ -287,8 +682,415 @@ func (in *StoragePoolZFS) ToLinstorStoragePool() lapi.StoragePool {
 	return lapi.StoragePool{
 		StoragePoolName: in.Name,
 		ProviderKind:    kind,
-		Props:           in.props(),
+		Props:           in.withProperties(in.props()),
+	}
+}
+
+func (in *StoragePoolZFS) ToPhysicalStorageCreate() lapi.PhysicalStorageCreate {
+	pool := in.ToLinstorStoragePool()
+
+	return lapi.PhysicalStorageCreate{
+		DevicePaths:  in.DevicePaths,
+		PoolName:     in.ZPool,
+		ProviderKind: pool.ProviderKind,
+		WithStoragePool: lapi.PhysicalStorageStoragePoolCreate{
+			Name:  in.Name,
+			Props: pool.Props,
+		},
+	}
+}
+
+// NeedsVDevSetup returns true if the zpool needs to be created by the operator. LINSTOR itself only creates zpools
+// with a single striped vdev.
+func (in *StoragePoolZFS) NeedsVDevSetup() bool {
+	return len(in.DevicePaths) != 0 && in.VDevLayout != "" && in.VDevLayout != ZFSVDevStripe
+}
+
+// ZPoolCreateCommand returns the command that creates the zpool from `devicePaths` using the configured vdev layout.
+func (in *StoragePoolZFS) ZPoolCreateCommand() ([]string, error) {
+	perVDev := len(in.DevicePaths)
+	if in.DevicesPerVDev != 0 {
+		perVDev = int(in.DevicesPerVDev)
+	}
+
+	if perVDev <= 0 || len(in.DevicePaths)%perVDev != 0 {
+		return nil, fmt.Errorf("zfsPool '%s': %d devices can't be split into vdevs of %d devices", in.Name, len(in.DevicePaths), perVDev)
+	}
+
+	cmd := []string{"zpool", "create", "-f", in.ZPool}
+
+	if in.VDevLayout == "" || in.VDevLayout == ZFSVDevStripe {
+		return append(cmd, in.DevicePaths...), nil
+	}
+
+	minDevices, ok := minDevicesPerVDev[in.VDevLayout]
+	if !ok {
+		return nil, fmt.Errorf("zfsPool '%s': unknown vdev layout '%s'", in.Name, in.VDevLayout)
+	}
+
+	if perVDev < minDevices {
+		return nil, fmt.Errorf("zfsPool '%s': vdev layout '%s' requires at least %d devices per vdev", in.Name, in.VDevLayout, minDevices)
+	}
+
+	for i := 0; i < len(in.DevicePaths); i += perVDev {
+		cmd = append(cmd, string(in.VDevLayout))
+		cmd = append(cmd, in.DevicePaths[i:i+perVDev]...)
+	}
+
+	return cmd, nil
+}
+
+// DestroyCommand returns the command that removes the zpool and wipes all devices of the pool.
+func (in *StoragePoolZFS) DestroyCommand() []string {
+	return append([]string{"sh", "-c", zfsDestroyScript, "zfs-destroy", in.ZPool}, in.DevicePaths...)
+}
+
+func (in *StoragePoolFile) props() map[string]string {
+	return map[string]string{
+		"StorDriver/FileDir":             in.Directory,
+		spec.LinstorRegistrationProperty: spec.Name,
+	}
+}
+
+// ToLinstorStoragePool returns lapi.StoragePool presentation of the StoragePoolFile
+func (in *StoragePoolFile) ToLinstorStoragePool() lapi.StoragePool {
+	return lapi.StoragePool{
+		StoragePoolName: in.Name,
+		ProviderKind:    lapi.FILE,
+		Props:           in.withProperties(in.props()),
+	}
+}
+
+// ToLinstorStoragePool returns lapi.StoragePool presentation of the StoragePoolFileThin
+func (in *StoragePoolFileThin) ToLinstorStoragePool() lapi.StoragePool {
+	return lapi.StoragePool{
+		StoragePoolName: in.Name,
+		ProviderKind:    lapi.FILE_THIN,
+		Props:           in.withProperties(in.props()),
+	}
+}
+
+// SatelliteNetInterface is an additional network interface registered on every satellite. The address of the
+// interface is determined per node, using exactly one of the configured sources.
+type SatelliteNetInterface struct {
+	// Name of the network interface in LINSTOR.
+	Name string `json:"name"`
+
+	// FromAnnotation is the name of a kubernetes node annotation containing the address of the interface.
+	// +optional
+	FromAnnotation string `json:"fromAnnotation,omitempty"`
+
+	// CIDR selects the first address of the kubernetes node in the given network.
+	// +optional
+	CIDR string `json:"cidr,omitempty"`
+
+	// MultusNetwork selects the address assigned to the satellite pod by the given Multus network.
+	// +optional
+	MultusNetwork string `json:"multusNetwork,omitempty"`
+}
+
+// Annotations set by Multus on pods with additional networks. The second one is deprecated, but still used by older
+// Multus versions.
+var multusNetworkStatusAnnotations = []string{
+	"k8s.v1.cni.cncf.io/network-status",
+	"k8s.v1.cni.cncf.io/networks-status",
+}
+
+// Validate checks that the interface has a name and exactly one address source.
+func (in *SatelliteNetInterface) Validate() error {
+	if in.Name == "" {
+		return fmt.Errorf("netInterfaces: `name` is required")
+	}
+
+	if in.Name == spec.LinstorDefaultNetInterface {
+		return fmt.Errorf("netInterfaces: name '%s' is reserved for the interface used by the satellite", in.Name)
+	}
+
+	sources := 0
+
+	for _, source := range []string{in.FromAnnotation, in.CIDR, in.MultusNetwork} {
+		if source != "" {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		return fmt.Errorf("netInterfaces: interface '%s' needs exactly one of `fromAnnotation`, `cidr` or `multusNetwork`", in.Name)
+	}
+
+	if in.CIDR != "" {
+		_, _, err := net.ParseCIDR(in.CIDR)
+		if err != nil {
+			return fmt.Errorf("netInterfaces: interface '%s' has invalid `cidr`: %w", in.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Address returns the address of the interface for the satellite pod running on the given node.
+func (in *SatelliteNetInterface) Address(node *corev1.Node, pod *corev1.Pod) (string, error) {
+	switch {
+	case in.FromAnnotation != "":
+		addr := node.Annotations[in.FromAnnotation]
+		if net.ParseIP(addr) == nil {
+			return "", fmt.Errorf("interface '%s': annotation '%s' on node '%s' is not a valid address: '%s'", in.Name, in.FromAnnotation, node.Name, addr)
+		}
+
+		return addr, nil
+	case in.CIDR != "":
+		_, network, err := net.ParseCIDR(in.CIDR)
+		if err != nil {
+			return "", fmt.Errorf("interface '%s': invalid cidr: %w", in.Name, err)
+		}
+
+		for _, addr := range node.Status.Addresses {
+			if addr.Type != corev1.NodeInternalIP && addr.Type != corev1.NodeExternalIP {
+				continue
+			}
+
+			ip := net.ParseIP(addr.Address)
+			if ip != nil && network.Contains(ip) {
+				return addr.Address, nil
+			}
+		}
+
+		return "", fmt.Errorf("interface '%s': node '%s' has no address in '%s'", in.Name, node.Name, in.CIDR)
+	case in.MultusNetwork != "":
+		type networkStatus struct {
+			Name string   `json:"name"`
+			IPs  []string `json:"ips"`
+		}
+
+		for _, annotation := range multusNetworkStatusAnnotations {
+			raw, ok := pod.Annotations[annotation]
+			if !ok {
+				continue
+			}
+
+			var statuses []networkStatus
+
+			err := json.Unmarshal([]byte(raw), &statuses)
+			if err != nil {
+				return "", fmt.Errorf("interface '%s': failed to parse annotation '%s' on pod '%s': %w", in.Name, annotation, pod.Name, err)
+			}
+
+			for _, status := range statuses {
+				// Multus reports the network as "<namespace>/<name>", unless it is in the same namespace as the pod.
+				if status.Name != in.MultusNetwork && !strings.HasSuffix(status.Name, "/"+in.MultusNetwork) {
+					continue
+				}
+
+				if len(status.IPs) == 0 {
+					return "", fmt.Errorf("interface '%s': network '%s' has no address on pod '%s'", in.Name, in.MultusNetwork, pod.Name)
+				}
+
+				return status.IPs[0], nil
+			}
+		}
+
+		return "", fmt.Errorf("interface '%s': network '%s' not attached to pod '%s'", in.Name, in.MultusNetwork, pod.Name)
+	default:
+		return "", fmt.Errorf("interface '%s': no address source configured", in.Name)
+	}
+}
+
+// NodeProperties sets LINSTOR properties on satellites running on selected kubernetes nodes.
+type NodeProperties struct {
+	// NodeSelector selects the kubernetes nodes by label. If empty, all nodes are selected.
+	// +optional
+	// +nullable
+	NodeSelector map[string]string `json:"nodeSelector"`
+
+	// Properties to set on the selected satellites.
+	Properties map[string]string `json:"properties"`
+}
+
+// Validate checks that the selector and property names are valid.
+func (in *NodeProperties) Validate() error {
+	_, err := labels.ValidatedSelectorFromSet(in.NodeSelector)
+	if err != nil {
+		return fmt.Errorf("nodeProperties: invalid nodeSelector: %w", err)
+	}
+
+	return ValidateNodePropertyNames(in.Properties)
+}
+
+// Matches returns true if the selector matches the labels of the node.
+func (in *NodeProperties) Matches(node *corev1.Node) bool {
+	return labels.SelectorFromSet(in.NodeSelector).Matches(labels.Set(node.Labels))
+}
+
+// ValidateNodePropertyNames checks that no property is empty or reserved for the operator.
+func ValidateNodePropertyNames(props map[string]string) error {
+	for k := range props {
+		if k == "" {
+			return fmt.Errorf("empty property name")
+		}
+
+		switch k {
+		case spec.LinstorRegistrationProperty, spec.LinstorSatelliteSetProperty, spec.LinstorManagedPropertiesProperty, spec.LinstorManagedInterfacesProperty:
+			return fmt.Errorf("property '%s' is reserved for the operator", k)
+		}
+	}
+
+	return nil
+}
+
+// NodeLabelSync selects the kubernetes node labels copied to LINSTOR as auxiliary node properties.
+type NodeLabelSync struct {
+	// Include is a list of patterns for label keys to copy. Patterns use shell glob syntax, in which "*" does not
+	// match "/". If empty, all labels are copied.
+	// +optional
+	// +nullable
+	Include []string `json:"include"`
+
+	// Exclude is a list of patterns for label keys not to copy, even if they match an include pattern.
+	// +optional
+	// +nullable
+	Exclude []string `json:"exclude"`
+
+	// Rename maps label keys to the name of the property in LINSTOR, without the "Aux/" prefix. Labels not listed
+	// keep their key as name.
+	// +optional
+	// +nullable
+	Rename map[string]string `json:"rename"`
+}
+
+// Validate checks that all patterns and property names are valid.
+func (in *NodeLabelSync) Validate() error {
+	for _, pattern := range append(append([]string{}, in.Include...), in.Exclude...) {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("nodeLabelSync: invalid pattern '%s': %w", pattern, err)
+		}
+	}
+
+	for label, name := range in.Rename {
+		if name == "" {
+			return fmt.Errorf("nodeLabelSync: empty property name for label '%s'", label)
+		}
+	}
+
+	return nil
+}
+
+// PropertyName returns the name of the auxiliary property for the label, without the "Aux/" prefix. Returns false
+// if the label should not be copied.
+func (in *NodeLabelSync) PropertyName(label string) (string, bool) {
+	if in == nil {
+		return label, true
+	}
+
+	if len(in.Include) != 0 && !matchesAny(in.Include, label) {
+		return "", false
+	}
+
+	if matchesAny(in.Exclude, label) {
+		return "", false
+	}
+
+	if name, ok := in.Rename[label]; ok {
+		return name, true
+	}
+
+	return label, true
+}
+
+func matchesAny(patterns []string, s string) bool {
+	for _, pattern := range patterns {
+		ok, _ := path.Match(pattern, s)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IPFamily is the address family used to register LINSTOR nodes.
+// +kubebuilder:validation:Enum=IPv4;IPv6
+type IPFamily string
+
+const (
+	IPv4 IPFamily = "IPv4"
+	IPv6 IPFamily = "IPv6"
+)
+
+// Matches returns true if the address belongs to the family.
+func (f IPFamily) Matches(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+
+	switch f {
+	case IPv4:
+		return ip.To4() != nil
+	case IPv6:
+		return ip.To4() == nil
+	default:
+		return false
+	}
+}
+
+// NetInterfaceName returns the name of the interface registered for the family, if it is not the primary family.
+func (f IPFamily) NetInterfaceName() string {
+	return spec.LinstorDefaultNetInterface + "-" + strings.ToLower(string(f))
+}
+
+// ValidateIPFamilies checks that only known families are requested, each at most once.
+func ValidateIPFamilies(families []IPFamily) error {
+	seen := make(map[IPFamily]struct{}, len(families))
+
+	for _, f := range families {
+		if f != IPv4 && f != IPv6 {
+			return fmt.Errorf("ipFamilies: unknown family '%s'", f)
+		}
+
+		if _, ok := seen[f]; ok {
+			return fmt.Errorf("ipFamilies: family '%s' requested twice", f)
+		}
+
+		seen[f] = struct{}{}
+	}
+
+	return nil
+}
+
+// FamilyAddresses selects one address per requested family from the given addresses, in the order of the families.
+// The addresses are expected in order of preference. If no family is requested, only the first address is returned.
+func FamilyAddresses(addresses []string, families []IPFamily) ([]string, error) {
+	if len(families) == 0 {
+		for _, addr := range addresses {
+			if addr != "" {
+				return []string{addr}, nil
+			}
+		}
+
+		return nil, fmt.Errorf("no address available")
 	}
+
+	result := make([]string, 0, len(families))
+
+	for _, f := range families {
+		found := false
+
+		for _, addr := range addresses {
+			if f.Matches(addr) {
+				result = append(result, addr)
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("no %s address available in %v", f, addresses)
+		}
+	}
+
+	return result, nil
 }
 
 // LinstorSSLConfig is the name of the k8s secret that holds the key (called `keystore.jks`) and
@@ -315,6 +1117,98 @@ func (lsc *LinstorSSLConfig) Type() string {
 	}
 }
 
+// LinstorSSLOptions configure the TLS connections between controller and satellites, if `sslSecret` is set.
+type LinstorSSLOptions struct {
+	// Protocol is the TLS protocol version used. Defaults to "TLSv1.2".
+	// +kubebuilder:validation:Enum=TLSv1.2;TLSv1.3
+	// +optional
+	Protocol string `json:"protocol"`
+
+	// Ciphers restricts the TLS cipher suites, using their Java names, for example "TLS_AES_256_GCM_SHA384". If
+	// empty, the defaults of the Java runtime are used.
+	// +optional
+	// +nullable
+	Ciphers []string `json:"ciphers"`
+
+	// PasswordSecret is the name of the k8s secret that holds the passwords for the java keystores in `sslSecret`,
+	// called `keystorePassword`, `keyPassword` and `truststorePassword`. Missing passwords default to "linstor".
+	// +optional
+	PasswordSecret string `json:"passwordSecret"`
+}
+
+// LinstorSSLPasswords are the passwords of the java keystores used for TLS connections.
+type LinstorSSLPasswords struct {
+	Keystore   string
+	Key        string
+	Truststore string
+}
+
+// GetProtocol returns the configured TLS protocol, or the default protocol.
+func (o *LinstorSSLOptions) GetProtocol() string {
+	if o == nil || o.Protocol == "" {
+		return spec.LinstorSslDefaultProtocol
+	}
+
+	return o.Protocol
+}
+
+// JavaOpts returns the JVM options restricting the cipher suites of TLS connections. Returns an empty string if all
+// default cipher suites are allowed.
+func (o *LinstorSSLOptions) JavaOpts() string {
+	if o == nil || len(o.Ciphers) == 0 {
+		return ""
+	}
+
+	ciphers := strings.Join(o.Ciphers, ",")
+
+	return fmt.Sprintf("-Djdk.tls.client.cipherSuites=%s -Djdk.tls.server.cipherSuites=%s", ciphers, ciphers)
+}
+
+// Validate checks that the cipher names can be passed to the JVM.
+func (o *LinstorSSLOptions) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	for _, cipher := range o.Ciphers {
+		if cipher == "" || strings.ContainsAny(cipher, ", \t\n") {
+			return fmt.Errorf("invalid cipher suite name '%s'", cipher)
+		}
+	}
+
+	return nil
+}
+
+// Passwords returns the keystore passwords, reading them from the password secret if set.
+func (o *LinstorSSLOptions) Passwords(fetchSecret func(name string) (map[string][]byte, error)) (*LinstorSSLPasswords, error) {
+	passwords := &LinstorSSLPasswords{
+		Keystore:   spec.LinstorSslDefaultPassword,
+		Key:        spec.LinstorSslDefaultPassword,
+		Truststore: spec.LinstorSslDefaultPassword,
+	}
+
+	if o == nil || o.PasswordSecret == "" {
+		return passwords, nil
+	}
+
+	data, err := fetchSecret(o.PasswordSecret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore passwords: %w", err)
+	}
+
+	for key, target := range map[string]*string{
+		spec.LinstorSslKeystorePasswordKey:   &passwords.Keystore,
+		spec.LinstorSslKeyPasswordKey:        &passwords.Key,
+		spec.LinstorSslTruststorePasswordKey: &passwords.Truststore,
+	} {
+		if val, ok := data[key]; ok {
+			*target = string(val)
+		}
+	}
+
+	return passwords, nil
+}
+
 type LinstorClientConfig struct {
 	// Name of the secret containing:
 	// (a) `ca.pem`: root certificate used to validate HTTPS connections with Linstor (PEM format, without password)
@@ -354,3 +1248,322 @@ const (
 	// ModuleInjectionDepsOnly means we only inject already present modules on the host for LINSTOR layers
 	ModuleInjectionDepsOnly = "DepsOnly"
 )
+
+// KernelModuleInjectionImage selects the kernel module injector image for nodes based on their operating system.
+type KernelModuleInjectionImage struct {
+	// Name of the node group using this image. Used as suffix for the name of the satellite DaemonSet.
+	Name string `json:"name"`
+
+	// OSImage is a pattern matched against the operating system reported by the node, for example
+	// "Ubuntu 22.04*". Patterns use shell glob syntax. If empty, all operating systems match.
+	// +optional
+	OSImage string `json:"osImage,omitempty"`
+
+	// KernelVersion is a pattern matched against the kernel version reported by the node, for example "*.el8.*".
+	// Patterns use shell glob syntax. If empty, all kernel versions match.
+	// +optional
+	KernelVersion string `json:"kernelVersion,omitempty"`
+
+	// Image is the kernel module injector image used on matching nodes.
+	Image string `json:"image"`
+}
+
+// Validate checks that the name is usable as part of a resource name, and that the patterns are valid.
+func (in *KernelModuleInjectionImage) Validate() error {
+	errs := validation.IsDNS1123Label(in.Name)
+	if len(errs) != 0 {
+		return fmt.Errorf("kernelModuleInjectionImages: invalid name '%s': %s", in.Name, strings.Join(errs, ", "))
+	}
+
+	if in.Image == "" {
+		return fmt.Errorf("kernelModuleInjectionImages: missing image for '%s'", in.Name)
+	}
+
+	for _, pattern := range []string{in.OSImage, in.KernelVersion} {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("kernelModuleInjectionImages: invalid pattern '%s' for '%s': %w", pattern, in.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Matches returns true if the operating system and kernel version of the node match the patterns.
+func (in *KernelModuleInjectionImage) Matches(node *corev1.Node) bool {
+	return matchesPattern(in.OSImage, node.Status.NodeInfo.OSImage) &&
+		matchesPattern(in.KernelVersion, node.Status.NodeInfo.KernelVersion)
+}
+
+// SelectKernelModuleInjectionImage returns the first entry matching the node, or nil if no entry matches.
+func SelectKernelModuleInjectionImage(images []*KernelModuleInjectionImage, node *corev1.Node) *KernelModuleInjectionImage {
+	for _, image := range images {
+		if image.Matches(node) {
+			return image
+		}
+	}
+
+	return nil
+}
+
+func matchesPattern(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	ok, _ := path.Match(pattern, s)
+
+	return ok
+}
+
+// SatelliteNodeOverride changes the container resources and JVM options of satellite pods on selected nodes.
+type SatelliteNodeOverride struct {
+	// Name of the override. Used as suffix for the name of the satellite DaemonSet.
+	Name string `json:"name"`
+
+	// NodeSelector selects the kubernetes nodes by label. If empty, all nodes are selected.
+	// +optional
+	// +nullable
+	NodeSelector map[string]string `json:"nodeSelector"`
+
+	// Resources replaces the resource requirements of the LINSTOR satellite container.
+	// +optional
+	// +nullable
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// KernelModuleInjectionResources replaces the resource requirements of the kernel module injector container.
+	// +optional
+	// +nullable
+	KernelModuleInjectionResources *corev1.ResourceRequirements `json:"kernelModuleInjectionResources,omitempty"`
+
+	// MonitoringResources sets the resource requirements of the drbd-reactor container.
+	// +optional
+	// +nullable
+	MonitoringResources *corev1.ResourceRequirements `json:"monitoringResources,omitempty"`
+
+	// JavaOpts are additional JVM options for the LINSTOR satellite, for example "-Xmx4g".
+	// +optional
+	JavaOpts string `json:"javaOpts,omitempty"`
+}
+
+// Validate checks that the name is usable as part of a resource name, and that the selector is valid.
+func (in *SatelliteNodeOverride) Validate() error {
+	errs := validation.IsDNS1123Label(in.Name)
+	if len(errs) != 0 {
+		return fmt.Errorf("nodeOverrides: invalid name '%s': %s", in.Name, strings.Join(errs, ", "))
+	}
+
+	_, err := labels.ValidatedSelectorFromSet(in.NodeSelector)
+	if err != nil {
+		return fmt.Errorf("nodeOverrides: invalid nodeSelector for '%s': %w", in.Name, err)
+	}
+
+	return nil
+}
+
+// Matches returns true if the selector matches the labels of the node.
+func (in *SatelliteNodeOverride) Matches(node *corev1.Node) bool {
+	return labels.SelectorFromSet(in.NodeSelector).Matches(labels.Set(node.Labels))
+}
+
+// SelectSatelliteNodeOverride returns the first entry matching the node, or nil if no entry matches.
+func SelectSatelliteNodeOverride(overrides []*SatelliteNodeOverride, node *corev1.Node) *SatelliteNodeOverride {
+	for _, override := range overrides {
+		if override.Matches(node) {
+			return override
+		}
+	}
+
+	return nil
+}
+
+// DefaultAutomaticPoolNameTemplate is the pool name template used by the automatic storage setup if none is set.
+const DefaultAutomaticPoolNameTemplate = "autopool-${device}"
+
+// validPoolName matches storage pool names accepted by LINSTOR and usable as volume group name.
+var validPoolName = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_-]*$`)
+
+// AutomaticStorageDevices selects the devices used by the automatic storage setup and groups them into storage pools.
+type AutomaticStorageDevices struct {
+	// Include selects devices matching any of the filters. If empty, all devices are included.
+	// +optional
+	// +nullable
+	Include []*DeviceFilter `json:"include,omitempty"`
+
+	// Exclude removes devices matching any of the filters, even if they are included.
+	// +optional
+	// +nullable
+	Exclude []*DeviceFilter `json:"exclude,omitempty"`
+
+	// PoolNameTemplate determines the storage pool name for a device. "${device}" is replaced by the device name,
+	// for example "sdb" for "/dev/sdb", "${media}" by "hdd" for rotational and "ssd" for other devices. Devices
+	// resulting in the same name are grouped into one storage pool. Defaults to "autopool-${device}".
+	// +optional
+	PoolNameTemplate string `json:"poolNameTemplate,omitempty"`
+}
+
+// DeviceFilter matches devices reported by LINSTOR. A device matches if it matches all configured conditions.
+type DeviceFilter struct {
+	// DevicePath is a pattern matched against the device path, for example "/dev/nvme*". Patterns use shell glob
+	// syntax.
+	// +optional
+	DevicePath string `json:"devicePath,omitempty"`
+
+	// ID is a pattern matched against the identifiers of the device, named like the links in /dev/disk/by-id:
+	// "wwn-<wwn>" and "<model>_<serial>", with spaces replaced by "_". Patterns use shell glob syntax.
+	// +optional
+	ID string `json:"id,omitempty"`
+
+	// MinSize is the minimum size of matching devices.
+	// +optional
+	MinSize *resource.Quantity `json:"minSize,omitempty"`
+
+	// MaxSize is the maximum size of matching devices.
+	// +optional
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+
+	// Rotational only matches rotational devices if true, and non-rotational devices if false.
+	// +optional
+	Rotational *bool `json:"rotational,omitempty"`
+}
+
+// DeviceInfo describes a device reported by LINSTOR.
+// +k8s:deepcopy-gen=false
+type DeviceInfo struct {
+	Path       string
+	Size       int64
+	Rotational bool
+	Model      string
+	Serial     string
+	WWN        string
+}
+
+// IDs returns the identifiers of the device, in the style of /dev/disk/by-id.
+func (d *DeviceInfo) IDs() []string {
+	var ids []string
+
+	if d.WWN != "" {
+		ids = append(ids, "wwn-"+d.WWN)
+	}
+
+	if d.Model != "" && d.Serial != "" {
+		ids = append(ids, strings.ReplaceAll(d.Model+"_"+d.Serial, " ", "_"))
+	}
+
+	return ids
+}
+
+// Validate checks that all filters and the pool name template are valid.
+func (in *AutomaticStorageDevices) Validate() error {
+	for _, filter := range append(append([]*DeviceFilter{}, in.Include...), in.Exclude...) {
+		err := filter.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	if in.PoolNameTemplate != "" {
+		name := expandPoolNameTemplate(in.PoolNameTemplate, "device", "media")
+		if !validPoolName.MatchString(name) {
+			return fmt.Errorf("automaticStorageDevices: invalid pool name template '%s'", in.PoolNameTemplate)
+		}
+	}
+
+	return nil
+}
+
+// Selects returns true if the device is included and not excluded. Without configuration, all devices are selected.
+func (in *AutomaticStorageDevices) Selects(device *DeviceInfo) bool {
+	if in == nil {
+		return true
+	}
+
+	for _, filter := range in.Exclude {
+		if filter.Matches(device) {
+			return false
+		}
+	}
+
+	if len(in.Include) == 0 {
+		return true
+	}
+
+	for _, filter := range in.Include {
+		if filter.Matches(device) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// PoolName returns the name of the storage pool the device should be part of.
+func (in *AutomaticStorageDevices) PoolName(device *DeviceInfo) string {
+	template := DefaultAutomaticPoolNameTemplate
+	if in != nil && in.PoolNameTemplate != "" {
+		template = in.PoolNameTemplate
+	}
+
+	media := "ssd"
+	if device.Rotational {
+		media = "hdd"
+	}
+
+	return expandPoolNameTemplate(template, path.Base(device.Path), media)
+}
+
+func expandPoolNameTemplate(template, device, media string) string {
+	return strings.NewReplacer("${device}", device, "${media}", media).Replace(template)
+}
+
+// Validate checks that the patterns are valid and the size range is not empty.
+func (in *DeviceFilter) Validate() error {
+	for _, pattern := range []string{in.DevicePath, in.ID} {
+		_, err := path.Match(pattern, "")
+		if err != nil {
+			return fmt.Errorf("automaticStorageDevices: invalid pattern '%s': %w", pattern, err)
+		}
+	}
+
+	if in.MinSize != nil && in.MaxSize != nil && in.MinSize.Cmp(*in.MaxSize) > 0 {
+		return fmt.Errorf("automaticStorageDevices: minSize %s is larger than maxSize %s", in.MinSize, in.MaxSize)
+	}
+
+	return nil
+}
+
+// Matches returns true if the device matches all conditions of the filter.
+func (in *DeviceFilter) Matches(device *DeviceInfo) bool {
+	if !matchesPattern(in.DevicePath, device.Path) {
+		return false
+	}
+
+	if in.ID != "" {
+		found := false
+
+		for _, id := range device.IDs() {
+			if matchesPattern(in.ID, id) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if in.MinSize != nil && device.Size < in.MinSize.Value() {
+		return false
+	}
+
+	if in.MaxSize != nil && device.Size > in.MaxSize.Value() {
+		return false
+	}
+
+	if in.Rotational != nil && device.Rotational != *in.Rotational {
+		return false
+	}
+
+	return true
+}
@@ -26,6 +26,9 @@ import (
 	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
 
 	lapi "github.com/LINBIT/golinstor/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestToLinstorStoragePool(t *testing.T) {
@@ -68,6 +71,64 @@ func TestToLinstorStoragePool(t *testing.T) {
 				},
 			},
 		},
+		{
+			&shared.StoragePoolLVM{
+				CommonStoragePoolOptions: shared.CommonStoragePoolOptions{
+					Name: "test0",
+					Properties: map[string]string{
+						"StorDriver/LvmVg":         "overridden",
+						"MaxOversubscriptionRatio": "5",
+						"PrefNic":                  "storage",
+					},
+				},
+				VolumeGroup: "test0VolumeGroup",
+			},
+			lapi.StoragePool{
+				StoragePoolName: "test0",
+				ProviderKind:    lapi.LVM,
+				Props: map[string]string{
+					"StorDriver/LvmVg":         "test0VolumeGroup",
+					"MaxOversubscriptionRatio": "5",
+					"PrefNic":                  "storage",
+					kubeSpec.LinstorManagedPropertiesProperty: "MaxOversubscriptionRatio,PrefNic",
+					kubeSpec.LinstorRegistrationProperty:      kubeSpec.Name,
+				},
+			},
+		},
+		{
+			&shared.StoragePoolFile{
+				CommonStoragePoolOptions: shared.CommonStoragePoolOptions{
+					Name: "test0",
+				},
+				Directory: "/var/lib/test0",
+			},
+			lapi.StoragePool{
+				StoragePoolName: "test0",
+				ProviderKind:    lapi.FILE,
+				Props: map[string]string{
+					"StorDriver/FileDir":                 "/var/lib/test0",
+					kubeSpec.LinstorRegistrationProperty: kubeSpec.Name,
+				},
+			},
+		},
+		{
+			&shared.StoragePoolFileThin{
+				StoragePoolFile: shared.StoragePoolFile{
+					CommonStoragePoolOptions: shared.CommonStoragePoolOptions{
+						Name: "test0",
+					},
+					Directory: "/var/lib/test0",
+				},
+			},
+			lapi.StoragePool{
+				StoragePoolName: "test0",
+				ProviderKind:    lapi.FILE_THIN,
+				Props: map[string]string{
+					"StorDriver/FileDir":                 "/var/lib/test0",
+					kubeSpec.LinstorRegistrationProperty: kubeSpec.Name,
+				},
+			},
+		},
 	}
 
 	for _, tt := range tableTest {
@@ -136,6 +197,30 @@ func TestToPhysicalStorageCreate(t *testing.T) {
 				},
 			},
 		},
+		{
+			&shared.StoragePoolZFS{
+				CommonStoragePoolOptions: shared.CommonStoragePoolOptions{
+					Name: "test0",
+				},
+				CommonPhysicalStorageOptions: shared.CommonPhysicalStorageOptions{
+					DevicePaths: []string{"/dev/vdb"},
+				},
+				ZPool: "test0ZPool",
+				Thin:  true,
+			},
+			lapi.PhysicalStorageCreate{
+				DevicePaths:  []string{"/dev/vdb"},
+				PoolName:     "test0ZPool",
+				ProviderKind: lapi.ZFS_THIN,
+				WithStoragePool: lapi.PhysicalStorageStoragePoolCreate{
+					Name: "test0",
+					Props: map[string]string{
+						"StorDriver/StorPoolName":            "test0ZPool",
+						kubeSpec.LinstorRegistrationProperty: kubeSpec.Name,
+					},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tableTest {
@@ -147,3 +232,667 @@ func TestToPhysicalStorageCreate(t *testing.T) {
 		}
 	}
 }
+
+func TestAllHostDirectories(t *testing.T) {
+	pools := shared.StoragePools{
+		FilePools: []*shared.StoragePoolFile{
+			{CommonStoragePoolOptions: shared.CommonStoragePoolOptions{Name: "a"}, Directory: "/var/lib/b"},
+			{CommonStoragePoolOptions: shared.CommonStoragePoolOptions{Name: "b"}, Directory: "/var/lib/a"},
+		},
+		FileThinPools: []*shared.StoragePoolFileThin{
+			{StoragePoolFile: shared.StoragePoolFile{CommonStoragePoolOptions: shared.CommonStoragePoolOptions{Name: "c"}, Directory: "/var/lib/b"}},
+		},
+	}
+
+	expected := []string{"/var/lib/a", "/var/lib/b"}
+
+	actual := pools.AllHostDirectories()
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestZPoolCreateCommand(t *testing.T) {
+	devices := []string{"/dev/vdb", "/dev/vdc", "/dev/vdd", "/dev/vde"}
+
+	tableTest := []struct {
+		name           string
+		layout         shared.ZFSVDevLayout
+		devicesPerVDev int32
+		expected       []string
+		expectErr      bool
+	}{
+		{
+			name:     "default-stripe",
+			expected: []string{"zpool", "create", "-f", "tank", "/dev/vdb", "/dev/vdc", "/dev/vdd", "/dev/vde"},
+		},
+		{
+			name:     "single-mirror",
+			layout:   shared.ZFSVDevMirror,
+			expected: []string{"zpool", "create", "-f", "tank", "mirror", "/dev/vdb", "/dev/vdc", "/dev/vdd", "/dev/vde"},
+		},
+		{
+			name:           "mirror-pairs",
+			layout:         shared.ZFSVDevMirror,
+			devicesPerVDev: 2,
+			expected:       []string{"zpool", "create", "-f", "tank", "mirror", "/dev/vdb", "/dev/vdc", "mirror", "/dev/vdd", "/dev/vde"},
+		},
+		{
+			name:     "raidz2",
+			layout:   shared.ZFSVDevRaidz2,
+			expected: []string{"zpool", "create", "-f", "tank", "raidz2", "/dev/vdb", "/dev/vdc", "/dev/vdd", "/dev/vde"},
+		},
+		{
+			name:           "uneven-split",
+			layout:         shared.ZFSVDevMirror,
+			devicesPerVDev: 3,
+			expectErr:      true,
+		},
+		{
+			name:           "too-few-devices",
+			layout:         shared.ZFSVDevRaidz3,
+			devicesPerVDev: 2,
+			expectErr:      true,
+		},
+	}
+
+	for _, tt := range tableTest {
+		pool := shared.StoragePoolZFS{
+			CommonStoragePoolOptions:     shared.CommonStoragePoolOptions{Name: "test0"},
+			CommonPhysicalStorageOptions: shared.CommonPhysicalStorageOptions{DevicePaths: devices},
+			ZPool:                        "tank",
+			VDevLayout:                   tt.layout,
+			DevicesPerVDev:               tt.devicesPerVDev,
+		}
+
+		actual, err := pool.ZPoolCreateCommand()
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got command %v", tt.name, actual)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(tt.expected, actual) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func TestVolumeGroupExtendCommand(t *testing.T) {
+	newDevices := []string{"/dev/vdc", "/dev/vdd"}
+	existing := shared.CommonPhysicalStorageOptions{DevicePaths: []string{"/dev/vdb", "/dev/vdc", "/dev/vdd"}}
+
+	tableTest := []struct {
+		name      string
+		pool      shared.VolumeGroupExtender
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name:     "lvm",
+			pool:     &shared.StoragePoolLVM{CommonPhysicalStorageOptions: existing, VolumeGroup: "vg0"},
+			expected: []string{"vgextend", "vg0", "/dev/vdc", "/dev/vdd"},
+		},
+		{
+			name:      "lvm-vdo",
+			pool:      &shared.StoragePoolLVM{CommonPhysicalStorageOptions: existing, VolumeGroup: "vg0", VDO: true},
+			expectErr: true,
+		},
+		{
+			name: "lvm-thin",
+			pool: &shared.StoragePoolLVMThin{CommonPhysicalStorageOptions: existing, ThinVolume: "thin0"},
+			expected: []string{
+				"sh", "-c", `vg="$1"; lv="$2"; shift 2; vgextend "$vg" "$@" && lvextend -l +100%FREE "$vg/$lv"`,
+				"lvm-thin-extend", "linstor_thin0", "thin0", "/dev/vdc", "/dev/vdd",
+			},
+		},
+		{
+			name:      "lvm-thin-existing-vg",
+			pool:      &shared.StoragePoolLVMThin{VolumeGroup: "vg0", ThinVolume: "thin0"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tableTest {
+		actual, err := tt.pool.VolumeGroupExtendCommand(newDevices)
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got command %v", tt.name, actual)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(tt.expected, actual) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func TestDestroyCommand(t *testing.T) {
+	devices := shared.CommonPhysicalStorageOptions{DevicePaths: []string{"/dev/vdb", "/dev/vdc"}}
+
+	tableTest := []struct {
+		name     string
+		pool     shared.StoragePoolRebuilder
+		expected []string
+	}{
+		{
+			name:     "lvm",
+			pool:     &shared.StoragePoolLVM{CommonPhysicalStorageOptions: devices, VolumeGroup: "vg0"},
+			expected: []string{"lvm-destroy", "vg0", "/dev/vdb", "/dev/vdc"},
+		},
+		{
+			name:     "lvm-thin",
+			pool:     &shared.StoragePoolLVMThin{CommonPhysicalStorageOptions: devices, ThinVolume: "thin0"},
+			expected: []string{"lvm-destroy", "linstor_thin0", "/dev/vdb", "/dev/vdc"},
+		},
+		{
+			name:     "zfs",
+			pool:     &shared.StoragePoolZFS{CommonPhysicalStorageOptions: devices, ZPool: "tank"},
+			expected: []string{"zfs-destroy", "tank", "/dev/vdb", "/dev/vdc"},
+		},
+	}
+
+	for _, tt := range tableTest {
+		actual := tt.pool.DestroyCommand()
+		if len(actual) < 3 || actual[0] != "sh" || actual[1] != "-c" {
+			t.Errorf("%s: expected shell script, got %v", tt.name, actual)
+			continue
+		}
+
+		if !reflect.DeepEqual(tt.expected, actual[3:]) {
+			t.Errorf("%s: expected arguments %v, got %v", tt.name, tt.expected, actual[3:])
+		}
+	}
+}
+
+func TestManagedPropertyKeys(t *testing.T) {
+	tableTest := []struct {
+		props    map[string]string
+		expected []string
+	}{
+		{
+			props:    map[string]string{},
+			expected: nil,
+		},
+		{
+			props:    map[string]string{kubeSpec.LinstorManagedPropertiesProperty: ""},
+			expected: nil,
+		},
+		{
+			props:    map[string]string{kubeSpec.LinstorManagedPropertiesProperty: shared.ManagedPropertiesValue(map[string]string{"b": "1", "a": "2"})},
+			expected: []string{"a", "b"},
+		},
+	}
+
+	for _, tt := range tableTest {
+		actual := shared.ManagedPropertyKeys(tt.props)
+		if !reflect.DeepEqual(tt.expected, actual) {
+			t.Errorf("expected %v, got %v", tt.expected, actual)
+		}
+	}
+}
+
+func TestSatelliteNetInterfaceAddress(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "node-a",
+			Annotations: map[string]string{"example.com/storage-ip": "10.20.0.5"},
+		},
+		Status: corev1.NodeStatus{
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "node-a"},
+				{Type: corev1.NodeInternalIP, Address: "192.168.1.5"},
+				{Type: corev1.NodeInternalIP, Address: "10.30.0.5"},
+			},
+		},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "satellite-a",
+			Annotations: map[string]string{
+				"k8s.v1.cni.cncf.io/network-status": `[{"name":"cbr0","ips":["172.16.0.3"]},{"name":"piraeus/storage","interface":"net1","ips":["10.40.0.5"]}]`,
+			},
+		},
+	}
+
+	tableTest := []struct {
+		name      string
+		nic       shared.SatelliteNetInterface
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "annotation",
+			nic:      shared.SatelliteNetInterface{Name: "storage", FromAnnotation: "example.com/storage-ip"},
+			expected: "10.20.0.5",
+		},
+		{
+			name:      "annotation-missing",
+			nic:       shared.SatelliteNetInterface{Name: "storage", FromAnnotation: "example.com/other-ip"},
+			expectErr: true,
+		},
+		{
+			name:     "cidr",
+			nic:      shared.SatelliteNetInterface{Name: "storage", CIDR: "10.30.0.0/16"},
+			expected: "10.30.0.5",
+		},
+		{
+			name:      "cidr-no-match",
+			nic:       shared.SatelliteNetInterface{Name: "storage", CIDR: "10.50.0.0/16"},
+			expectErr: true,
+		},
+		{
+			name:     "multus",
+			nic:      shared.SatelliteNetInterface{Name: "storage", MultusNetwork: "storage"},
+			expected: "10.40.0.5",
+		},
+		{
+			name:     "multus-namespaced",
+			nic:      shared.SatelliteNetInterface{Name: "storage", MultusNetwork: "piraeus/storage"},
+			expected: "10.40.0.5",
+		},
+		{
+			name:      "multus-not-attached",
+			nic:       shared.SatelliteNetInterface{Name: "storage", MultusNetwork: "backup"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tableTest {
+		actual, err := tt.nic.Address(node, pod)
+		if tt.expectErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got address %s", tt.name, actual)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+
+		if tt.expected != actual {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, actual)
+		}
+	}
+}
+
+func TestSatelliteNetInterfaceValidate(t *testing.T) {
+	tableTest := []struct {
+		name      string
+		nic       shared.SatelliteNetInterface
+		expectErr bool
+	}{
+		{name: "valid", nic: shared.SatelliteNetInterface{Name: "storage", CIDR: "10.30.0.0/16"}},
+		{name: "no-name", nic: shared.SatelliteNetInterface{CIDR: "10.30.0.0/16"}, expectErr: true},
+		{name: "reserved-name", nic: shared.SatelliteNetInterface{Name: "default", CIDR: "10.30.0.0/16"}, expectErr: true},
+		{name: "no-source", nic: shared.SatelliteNetInterface{Name: "storage"}, expectErr: true},
+		{name: "two-sources", nic: shared.SatelliteNetInterface{Name: "storage", CIDR: "10.30.0.0/16", MultusNetwork: "storage"}, expectErr: true},
+		{name: "invalid-cidr", nic: shared.SatelliteNetInterface{Name: "storage", CIDR: "10.30.0.0"}, expectErr: true},
+	}
+
+	for _, tt := range tableTest {
+		err := tt.nic.Validate()
+		if tt.expectErr != (err != nil) {
+			t.Errorf("%s: expected error: %t, got: %v", tt.name, tt.expectErr, err)
+		}
+	}
+}
+
+func TestNodeLabelSyncPropertyName(t *testing.T) {
+	sync := &shared.NodeLabelSync{
+		Include: []string{"topology.kubernetes.io/*", "example.com/*"},
+		Exclude: []string{"example.com/internal-*"},
+		Rename:  map[string]string{"topology.kubernetes.io/zone": "zone"},
+	}
+
+	tableTest := []struct {
+		sync         *shared.NodeLabelSync
+		label        string
+		expected     string
+		expectedSync bool
+	}{
+		{sync: nil, label: "beta.kubernetes.io/arch", expected: "beta.kubernetes.io/arch", expectedSync: true},
+		{sync: sync, label: "beta.kubernetes.io/arch", expectedSync: false},
+		{sync: sync, label: "topology.kubernetes.io/region", expected: "topology.kubernetes.io/region", expectedSync: true},
+		{sync: sync, label: "topology.kubernetes.io/zone", expected: "zone", expectedSync: true},
+		{sync: sync, label: "example.com/rack", expected: "example.com/rack", expectedSync: true},
+		{sync: sync, label: "example.com/internal-id", expectedSync: false},
+		{sync: &shared.NodeLabelSync{Exclude: []string{"*.cloud.example.com/*"}}, label: "node.cloud.example.com/id", expectedSync: false},
+		{sync: &shared.NodeLabelSync{Exclude: []string{"*.cloud.example.com/*"}}, label: "example.com/rack", expected: "example.com/rack", expectedSync: true},
+	}
+
+	for _, tt := range tableTest {
+		actual, ok := tt.sync.PropertyName(tt.label)
+		if ok != tt.expectedSync || actual != tt.expected {
+			t.Errorf("%s: expected (%s, %t), got (%s, %t)", tt.label, tt.expected, tt.expectedSync, actual, ok)
+		}
+	}
+}
+
+func TestNodeLabelSyncValidate(t *testing.T) {
+	valid := shared.NodeLabelSync{Include: []string{"example.com/*"}, Rename: map[string]string{"example.com/rack": "rack"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalidPattern := shared.NodeLabelSync{Exclude: []string{"example.com/[rack"}}
+	if err := invalidPattern.Validate(); err == nil {
+		t.Errorf("expected error for invalid pattern")
+	}
+
+	emptyName := shared.NodeLabelSync{Rename: map[string]string{"example.com/rack": ""}}
+	if err := emptyName.Validate(); err == nil {
+		t.Errorf("expected error for empty property name")
+	}
+}
+
+func TestNodePropertiesMatches(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"example.com/site": "a", "example.com/rack": "1"}}}
+
+	tableTest := []struct {
+		selector map[string]string
+		expected bool
+	}{
+		{selector: nil, expected: true},
+		{selector: map[string]string{"example.com/site": "a"}, expected: true},
+		{selector: map[string]string{"example.com/site": "a", "example.com/rack": "2"}, expected: false},
+		{selector: map[string]string{"example.com/row": "1"}, expected: false},
+	}
+
+	for _, tt := range tableTest {
+		props := shared.NodeProperties{NodeSelector: tt.selector}
+		if actual := props.Matches(node); actual != tt.expected {
+			t.Errorf("%v: expected %t, got %t", tt.selector, tt.expected, actual)
+		}
+	}
+}
+
+func TestNodePropertiesValidate(t *testing.T) {
+	valid := shared.NodeProperties{
+		NodeSelector: map[string]string{"example.com/site": "a"},
+		Properties:   map[string]string{"AutoplaceTarget": "false", "Aux/site": "a"},
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalidSelector := shared.NodeProperties{NodeSelector: map[string]string{"example.com/site": "a b"}}
+	if err := invalidSelector.Validate(); err == nil {
+		t.Errorf("expected error for invalid selector")
+	}
+
+	reserved := shared.NodeProperties{Properties: map[string]string{kubeSpec.LinstorRegistrationProperty: "me"}}
+	if err := reserved.Validate(); err == nil {
+		t.Errorf("expected error for reserved property")
+	}
+
+	reservedOwner := shared.NodeProperties{Properties: map[string]string{kubeSpec.LinstorSatelliteSetProperty: "other/set"}}
+	if err := reservedOwner.Validate(); err == nil {
+		t.Errorf("expected error for reserved ownership property")
+	}
+}
+
+func TestSelectKernelModuleInjectionImage(t *testing.T) {
+	images := []*shared.KernelModuleInjectionImage{
+		{Name: "jammy", OSImage: "Ubuntu 22.04*", Image: "drbd9-jammy"},
+		{Name: "focal", OSImage: "Ubuntu 20.04*", Image: "drbd9-focal"},
+		{Name: "rhel8", KernelVersion: "*.el8*", Image: "drbd9-almalinux8"},
+	}
+
+	node := func(osImage, kernelVersion string) *corev1.Node {
+		return &corev1.Node{
+			Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OSImage: osImage, KernelVersion: kernelVersion}},
+		}
+	}
+
+	testcases := []struct {
+		name     string
+		node     *corev1.Node
+		expected string
+	}{
+		{name: "jammy", node: node("Ubuntu 22.04.1 LTS", "5.15.0-52-generic"), expected: "jammy"},
+		{name: "focal", node: node("Ubuntu 20.04.5 LTS", "5.4.0-131-generic"), expected: "focal"},
+		{name: "rhel8", node: node("Red Hat Enterprise Linux 8.6 (Ootpa)", "4.18.0-372.9.1.el8.x86_64"), expected: "rhel8"},
+		{name: "no-match", node: node("Flatcar Container Linux by Kinvolk 3227.2.4", "5.15.70-flatcar"), expected: ""},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := ""
+			if image := shared.SelectKernelModuleInjectionImage(images, tcase.node); image != nil {
+				actual = image.Name
+			}
+
+			if actual != tcase.expected {
+				t.Errorf("expected: %q, actual: %q", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestKernelModuleInjectionImageValidate(t *testing.T) {
+	valid := shared.KernelModuleInjectionImage{Name: "focal", OSImage: "Ubuntu 20.04*", Image: "drbd9-focal"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := []shared.KernelModuleInjectionImage{
+		{Name: "Focal", Image: "drbd9-focal"},
+		{Name: "focal"},
+		{Name: "focal", OSImage: "Ubuntu [20", Image: "drbd9-focal"},
+	}
+
+	for i := range invalid {
+		if err := invalid[i].Validate(); err == nil {
+			t.Errorf("expected error for %+v", invalid[i])
+		}
+	}
+}
+
+func TestSelectSatelliteNodeOverride(t *testing.T) {
+	overrides := []*shared.SatelliteNodeOverride{
+		{Name: "large", NodeSelector: map[string]string{"example.com/size": "large"}},
+		{Name: "storage", NodeSelector: map[string]string{"example.com/role": "storage"}},
+	}
+
+	node := func(labels map[string]string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+	}
+
+	testcases := []struct {
+		name     string
+		node     *corev1.Node
+		expected string
+	}{
+		{name: "large", node: node(map[string]string{"example.com/size": "large"}), expected: "large"},
+		{name: "storage", node: node(map[string]string{"example.com/role": "storage"}), expected: "storage"},
+		{name: "first-match", node: node(map[string]string{"example.com/size": "large", "example.com/role": "storage"}), expected: "large"},
+		{name: "no-match", node: node(map[string]string{"example.com/size": "small"}), expected: ""},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := ""
+			if override := shared.SelectSatelliteNodeOverride(overrides, tcase.node); override != nil {
+				actual = override.Name
+			}
+
+			if actual != tcase.expected {
+				t.Errorf("expected: %q, actual: %q", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSatelliteNodeOverrideValidate(t *testing.T) {
+	valid := shared.SatelliteNodeOverride{Name: "large", NodeSelector: map[string]string{"example.com/size": "large"}, JavaOpts: "-Xmx4g"}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := []shared.SatelliteNodeOverride{
+		{Name: "Large"},
+		{Name: ""},
+		{Name: "large", NodeSelector: map[string]string{"example.com/size": "very large"}},
+	}
+
+	for i := range invalid {
+		if err := invalid[i].Validate(); err == nil {
+			t.Errorf("expected error for %+v", invalid[i])
+		}
+	}
+}
+
+func TestDeviceFilterMatches(t *testing.T) {
+	yes := true
+	no := false
+
+	ssd := &shared.DeviceInfo{Path: "/dev/nvme0n1", Size: 500 << 30, Model: "Samsung SSD 970", Serial: "S123", WWN: "eui.0025"}
+	hdd := &shared.DeviceInfo{Path: "/dev/sdb", Size: 4 << 40, Rotational: true, WWN: "0x5000c500a1b2c3d4"}
+
+	testcases := []struct {
+		name     string
+		filter   shared.DeviceFilter
+		expected []bool
+	}{
+		{name: "empty", filter: shared.DeviceFilter{}, expected: []bool{true, true}},
+		{name: "device-path", filter: shared.DeviceFilter{DevicePath: "/dev/sd*"}, expected: []bool{false, true}},
+		{name: "model-serial", filter: shared.DeviceFilter{ID: "Samsung_SSD_*"}, expected: []bool{true, false}},
+		{name: "wwn", filter: shared.DeviceFilter{ID: "wwn-0x5000c500*"}, expected: []bool{false, true}},
+		{name: "min-size", filter: shared.DeviceFilter{MinSize: resource.NewQuantity(1<<40, resource.BinarySI)}, expected: []bool{false, true}},
+		{name: "max-size", filter: shared.DeviceFilter{MaxSize: resource.NewQuantity(1<<40, resource.BinarySI)}, expected: []bool{true, false}},
+		{name: "rotational", filter: shared.DeviceFilter{Rotational: &yes}, expected: []bool{false, true}},
+		{name: "non-rotational", filter: shared.DeviceFilter{Rotational: &no, DevicePath: "/dev/sd*"}, expected: []bool{false, false}},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := []bool{tcase.filter.Matches(ssd), tcase.filter.Matches(hdd)}
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %v, actual: %v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestAutomaticStorageDevicesPoolName(t *testing.T) {
+	hdd := &shared.DeviceInfo{Path: "/dev/sdb", Rotational: true}
+
+	var unset *shared.AutomaticStorageDevices
+	if unset.PoolName(hdd) != "autopool-sdb" {
+		t.Errorf("expected: %q, actual: %q", "autopool-sdb", unset.PoolName(hdd))
+	}
+
+	custom := &shared.AutomaticStorageDevices{PoolNameTemplate: "${media}-${device}"}
+	if custom.PoolName(hdd) != "hdd-sdb" {
+		t.Errorf("expected: %q, actual: %q", "hdd-sdb", custom.PoolName(hdd))
+	}
+}
+
+func TestAutomaticStorageDevicesValidate(t *testing.T) {
+	valid := shared.AutomaticStorageDevices{
+		Include:          []*shared.DeviceFilter{{DevicePath: "/dev/nvme*", MinSize: resource.NewQuantity(1<<30, resource.BinarySI)}},
+		PoolNameTemplate: "pool_${media}",
+	}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := []shared.AutomaticStorageDevices{
+		{Include: []*shared.DeviceFilter{{DevicePath: "/dev/[sd"}}},
+		{Exclude: []*shared.DeviceFilter{{MinSize: resource.NewQuantity(2<<30, resource.BinarySI), MaxSize: resource.NewQuantity(1<<30, resource.BinarySI)}}},
+		{PoolNameTemplate: "pool-${unknown}"},
+		{PoolNameTemplate: "pool/${device}"},
+	}
+
+	for i := range invalid {
+		if err := invalid[i].Validate(); err == nil {
+			t.Errorf("expected error for %+v", invalid[i])
+		}
+	}
+}
+
+func TestLinstorSSLOptions(t *testing.T) {
+	var unset *shared.LinstorSSLOptions
+
+	if unset.GetProtocol() != kubeSpec.LinstorSslDefaultProtocol {
+		t.Errorf("expected default protocol, got '%s'", unset.GetProtocol())
+	}
+
+	if unset.JavaOpts() != "" {
+		t.Errorf("expected no java options, got '%s'", unset.JavaOpts())
+	}
+
+	defaults, err := unset.Passwords(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if defaults.Keystore != kubeSpec.LinstorSslDefaultPassword || defaults.Key != kubeSpec.LinstorSslDefaultPassword || defaults.Truststore != kubeSpec.LinstorSslDefaultPassword {
+		t.Errorf("expected default passwords, got %+v", defaults)
+	}
+
+	opts := &shared.LinstorSSLOptions{
+		Protocol:       "TLSv1.3",
+		Ciphers:        []string{"TLS_AES_256_GCM_SHA384", "TLS_CHACHA20_POLY1305_SHA256"},
+		PasswordSecret: "ssl-passwords",
+	}
+
+	if opts.GetProtocol() != "TLSv1.3" {
+		t.Errorf("expected configured protocol, got '%s'", opts.GetProtocol())
+	}
+
+	expectedOpts := "-Djdk.tls.client.cipherSuites=TLS_AES_256_GCM_SHA384,TLS_CHACHA20_POLY1305_SHA256 -Djdk.tls.server.cipherSuites=TLS_AES_256_GCM_SHA384,TLS_CHACHA20_POLY1305_SHA256"
+	if opts.JavaOpts() != expectedOpts {
+		t.Errorf("expected java options '%s', got '%s'", expectedOpts, opts.JavaOpts())
+	}
+
+	passwords, err := opts.Passwords(func(name string) (map[string][]byte, error) {
+		if name != "ssl-passwords" {
+			t.Errorf("unexpected secret '%s'", name)
+		}
+
+		return map[string][]byte{
+			kubeSpec.LinstorSslKeystorePasswordKey: []byte("store"),
+			kubeSpec.LinstorSslKeyPasswordKey:      []byte("key"),
+		}, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := &shared.LinstorSSLPasswords{Keystore: "store", Key: "key", Truststore: kubeSpec.LinstorSslDefaultPassword}
+	if *passwords != *expected {
+		t.Errorf("expected passwords %+v, got %+v", expected, passwords)
+	}
+
+	if err := opts.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	invalid := &shared.LinstorSSLOptions{Ciphers: []string{"TLS_AES_256_GCM_SHA384,TLS_AES_128_GCM_SHA256"}}
+	if err := invalid.Validate(); err == nil {
+		t.Errorf("expected error for invalid cipher name")
+	}
+}
@@ -21,6 +21,48 @@ limitations under the License.
 
 package shared
 
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutomaticStorageDevices) DeepCopyInto(out *AutomaticStorageDevices) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]*DeviceFilter, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DeviceFilter)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]*DeviceFilter, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DeviceFilter)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutomaticStorageDevices.
+func (in *AutomaticStorageDevices) DeepCopy() *AutomaticStorageDevices {
+	if in == nil {
+		return nil
+	}
+	out := new(AutomaticStorageDevices)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonPhysicalStorageOptions) DeepCopyInto(out *CommonPhysicalStorageOptions) {
 	*out = *in
@@ -29,6 +71,11 @@ func (in *CommonPhysicalStorageOptions) DeepCopyInto(out *CommonPhysicalStorageO
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ReplaceDevices != nil {
+		in, out := &in.ReplaceDevices, &out.ReplaceDevices
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -45,6 +92,13 @@ func (in *CommonPhysicalStorageOptions) DeepCopy() *CommonPhysicalStorageOptions
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonStoragePoolOptions) DeepCopyInto(out *CommonStoragePoolOptions) {
 	*out = *in
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	return
 }
 
@@ -58,6 +112,69 @@ func (in *CommonStoragePoolOptions) DeepCopy() *CommonStoragePoolOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeviceFilter) DeepCopyInto(out *DeviceFilter) {
+	*out = *in
+	if in.MinSize != nil {
+		in, out := &in.MinSize, &out.MinSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.MaxSize != nil {
+		in, out := &in.MaxSize, &out.MaxSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Rotational != nil {
+		in, out := &in.Rotational, &out.Rotational
+		*out = new(bool)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeviceFilter.
+func (in *DeviceFilter) DeepCopy() *DeviceFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(DeviceFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelModuleInjectionImage) DeepCopyInto(out *KernelModuleInjectionImage) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelModuleInjectionImage.
+func (in *KernelModuleInjectionImage) DeepCopy() *KernelModuleInjectionImage {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelModuleInjectionImage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KernelModuleStatus) DeepCopyInto(out *KernelModuleStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KernelModuleStatus.
+func (in *KernelModuleStatus) DeepCopy() *KernelModuleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KernelModuleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LinstorClientConfig) DeepCopyInto(out *LinstorClientConfig) {
 	*out = *in
@@ -74,6 +191,106 @@ func (in *LinstorClientConfig) DeepCopy() *LinstorClientConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorSSLOptions) DeepCopyInto(out *LinstorSSLOptions) {
+	*out = *in
+	if in.Ciphers != nil {
+		in, out := &in.Ciphers, &out.Ciphers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorSSLOptions.
+func (in *LinstorSSLOptions) DeepCopy() *LinstorSSLOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorSSLOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorSSLPasswords) DeepCopyInto(out *LinstorSSLPasswords) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorSSLPasswords.
+func (in *LinstorSSLPasswords) DeepCopy() *LinstorSSLPasswords {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorSSLPasswords)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeLabelSync) DeepCopyInto(out *NodeLabelSync) {
+	*out = *in
+	if in.Include != nil {
+		in, out := &in.Include, &out.Include
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exclude != nil {
+		in, out := &in.Exclude, &out.Exclude
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rename != nil {
+		in, out := &in.Rename, &out.Rename
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeLabelSync.
+func (in *NodeLabelSync) DeepCopy() *NodeLabelSync {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeLabelSync)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeProperties) DeepCopyInto(out *NodeProperties) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeProperties.
+func (in *NodeProperties) DeepCopy() *NodeProperties {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeProperties)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
 	*out = *in
@@ -90,6 +307,115 @@ func (in *NodeStatus) DeepCopy() *NodeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceHealthDetail) DeepCopyInto(out *ResourceHealthDetail) {
+	*out = *in
+	if in.DiskStates != nil {
+		in, out := &in.DiskStates, &out.DiskStates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceHealthDetail.
+func (in *ResourceHealthDetail) DeepCopy() *ResourceHealthDetail {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceHealthDetail)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceHealthStatus) DeepCopyInto(out *ResourceHealthStatus) {
+	*out = *in
+	if in.DiskStates != nil {
+		in, out := &in.DiskStates, &out.DiskStates
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.DegradedResources != nil {
+		in, out := &in.DegradedResources, &out.DegradedResources
+		*out = make([]*ResourceHealthDetail, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(ResourceHealthDetail)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceHealthStatus.
+func (in *ResourceHealthStatus) DeepCopy() *ResourceHealthStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceHealthStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SatelliteNetInterface) DeepCopyInto(out *SatelliteNetInterface) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SatelliteNetInterface.
+func (in *SatelliteNetInterface) DeepCopy() *SatelliteNetInterface {
+	if in == nil {
+		return nil
+	}
+	out := new(SatelliteNetInterface)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SatelliteNodeOverride) DeepCopyInto(out *SatelliteNodeOverride) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.KernelModuleInjectionResources != nil {
+		in, out := &in.KernelModuleInjectionResources, &out.KernelModuleInjectionResources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MonitoringResources != nil {
+		in, out := &in.MonitoringResources, &out.MonitoringResources
+		*out = new(v1.ResourceRequirements)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SatelliteNodeOverride.
+func (in *SatelliteNodeOverride) DeepCopy() *SatelliteNodeOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(SatelliteNodeOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SatelliteStatus) DeepCopyInto(out *SatelliteStatus) {
 	*out = *in
@@ -101,10 +427,38 @@ func (in *SatelliteStatus) DeepCopyInto(out *SatelliteStatus) {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(StoragePoolStatus)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Properties != nil {
+		in, out := &in.Properties, &out.Properties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.KernelModule != nil {
+		in, out := &in.KernelModule, &out.KernelModule
+		*out = new(KernelModuleStatus)
+		**out = **in
+	}
+	if in.PreflightChecks != nil {
+		in, out := &in.PreflightChecks, &out.PreflightChecks
+		*out = make([]*PreflightCheck, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PreflightCheck)
 				**out = **in
 			}
 		}
 	}
+	if in.ResourceHealth != nil {
+		in, out := &in.ResourceHealth, &out.ResourceHealth
+		*out = new(ResourceHealthStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -118,10 +472,70 @@ func (in *SatelliteStatus) DeepCopy() *SatelliteStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoragePoolFailureStatus) DeepCopyInto(out *StoragePoolFailureStatus) {
+	*out = *in
+	if in.DevicePaths != nil {
+		in, out := &in.DevicePaths, &out.DevicePaths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FailedResources != nil {
+		in, out := &in.FailedResources, &out.FailedResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoragePoolFailureStatus.
+func (in *StoragePoolFailureStatus) DeepCopy() *StoragePoolFailureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StoragePoolFailureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoragePoolFile) DeepCopyInto(out *StoragePoolFile) {
+	*out = *in
+	in.CommonStoragePoolOptions.DeepCopyInto(&out.CommonStoragePoolOptions)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoragePoolFile.
+func (in *StoragePoolFile) DeepCopy() *StoragePoolFile {
+	if in == nil {
+		return nil
+	}
+	out := new(StoragePoolFile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoragePoolFileThin) DeepCopyInto(out *StoragePoolFileThin) {
+	*out = *in
+	in.StoragePoolFile.DeepCopyInto(&out.StoragePoolFile)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoragePoolFileThin.
+func (in *StoragePoolFileThin) DeepCopy() *StoragePoolFileThin {
+	if in == nil {
+		return nil
+	}
+	out := new(StoragePoolFileThin)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StoragePoolLVM) DeepCopyInto(out *StoragePoolLVM) {
 	*out = *in
-	out.CommonStoragePoolOptions = in.CommonStoragePoolOptions
+	in.CommonStoragePoolOptions.DeepCopyInto(&out.CommonStoragePoolOptions)
 	in.CommonPhysicalStorageOptions.DeepCopyInto(&out.CommonPhysicalStorageOptions)
 	return
 }
@@ -139,7 +553,7 @@ func (in *StoragePoolLVM) DeepCopy() *StoragePoolLVM {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StoragePoolLVMThin) DeepCopyInto(out *StoragePoolLVMThin) {
 	*out = *in
-	out.CommonStoragePoolOptions = in.CommonStoragePoolOptions
+	in.CommonStoragePoolOptions.DeepCopyInto(&out.CommonStoragePoolOptions)
 	in.CommonPhysicalStorageOptions.DeepCopyInto(&out.CommonPhysicalStorageOptions)
 	return
 }
@@ -154,9 +568,40 @@ func (in *StoragePoolLVMThin) DeepCopy() *StoragePoolLVMThin {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StoragePoolMigrationStatus) DeepCopyInto(out *StoragePoolMigrationStatus) {
+	*out = *in
+	if in.RemainingResources != nil {
+		in, out := &in.RemainingResources, &out.RemainingResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StoragePoolMigrationStatus.
+func (in *StoragePoolMigrationStatus) DeepCopy() *StoragePoolMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StoragePoolMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StoragePoolStatus) DeepCopyInto(out *StoragePoolStatus) {
 	*out = *in
+	if in.Migration != nil {
+		in, out := &in.Migration, &out.Migration
+		*out = new(StoragePoolMigrationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Failure != nil {
+		in, out := &in.Failure, &out.Failure
+		*out = new(StoragePoolFailureStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -173,7 +618,8 @@ func (in *StoragePoolStatus) DeepCopy() *StoragePoolStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StoragePoolZFS) DeepCopyInto(out *StoragePoolZFS) {
 	*out = *in
-	out.CommonStoragePoolOptions = in.CommonStoragePoolOptions
+	in.CommonStoragePoolOptions.DeepCopyInto(&out.CommonStoragePoolOptions)
+	in.CommonPhysicalStorageOptions.DeepCopyInto(&out.CommonPhysicalStorageOptions)
 	return
 }
 
@@ -219,7 +665,29 @@ func (in *StoragePools) DeepCopyInto(out *StoragePools) {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(StoragePoolZFS)
-				**out = **in
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.FilePools != nil {
+		in, out := &in.FilePools, &out.FilePools
+		*out = make([]*StoragePoolFile, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(StoragePoolFile)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.FileThinPools != nil {
+		in, out := &in.FileThinPools, &out.FileThinPools
+		*out = make([]*StoragePoolFileThin, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(StoragePoolFileThin)
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
@@ -55,6 +55,11 @@ type LinstorControllerSpec struct {
 	// +optional
 	SslConfig *shared.LinstorSSLConfig `json:"sslSecret"`
 
+	// SslOptions configure the TLS protocol, cipher suites and keystore passwords used with `sslSecret`
+	// +optional
+	// +nullable
+	SslOptions *shared.LinstorSSLOptions `json:"sslOptions"`
+
 	// DrbdRepoCred is the name of the kubernetes secret that holds the credential for the
 	// DRBD repositories
 	DrbdRepoCred string `json:"drbdRepoCred"`
@@ -107,6 +112,14 @@ type LinstorControllerSpec struct {
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName"`
 
+	// IPFamilies selects the address families used to register the controller pods in LINSTOR. The address of the first
+	// family is used for the "default" interface, additional families are registered as "default-ipv4" or
+	// "default-ipv6". If not set, the primary pod address is used.
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:MaxItems=2
+	IPFamilies []shared.IPFamily `json:"ipFamilies"`
+
 	shared.LinstorClientConfig `json:",inline"`
 }
 
@@ -0,0 +1,105 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LinstorNodeMaintenanceSpec defines the desired state of LinstorNodeMaintenance
+type LinstorNodeMaintenanceSpec struct {
+	// NodeName is the name of the kubernetes node to prepare for maintenance.
+	NodeName string `json:"nodeName"`
+
+	// Cluster URL of the linstor controller.
+	// If not set, will be determined from the LinstorController resource in the same namespace.
+	// +optional
+	ControllerEndpoint string `json:"controllerEndpoint"`
+
+	shared.LinstorClientConfig `json:",inline"`
+}
+
+// LinstorNodeMaintenanceStatus defines the observed state of LinstorNodeMaintenance
+type LinstorNodeMaintenanceStatus struct {
+	// Phase of the maintenance. One of "Cordoning", "Evacuating", "Draining", "Ready" or "Restoring".
+	Phase string `json:"phase"`
+
+	// Resources that still have a replica on the node.
+	// +optional
+	// +nullable
+	Resources []*NodeMaintenanceResourceStatus `json:"resources"`
+
+	// Errors remaining that will trigger reconciliations.
+	Errors []string `json:"errors"`
+}
+
+// NodeMaintenanceResourceStatus reports the evacuation progress of a single resource.
+type NodeMaintenanceResourceStatus struct {
+	// Name of the LINSTOR resource.
+	Name string `json:"name"`
+	// State of the evacuation, either "Syncing" or "Removing".
+	State string `json:"state"`
+}
+
+const (
+	// NodeMaintenanceCordoning means the node is marked as unschedulable in kubernetes and LINSTOR.
+	NodeMaintenanceCordoning = "Cordoning"
+	// NodeMaintenanceEvacuating means replicas on the node are moved to other nodes.
+	NodeMaintenanceEvacuating = "Evacuating"
+	// NodeMaintenanceDraining means pods are evicted from the node.
+	NodeMaintenanceDraining = "Draining"
+	// NodeMaintenanceReady means the node is ready for maintenance.
+	NodeMaintenanceReady = "Ready"
+	// NodeMaintenanceRestoring means the node is made schedulable again.
+	NodeMaintenanceRestoring = "Restoring"
+
+	// NodeMaintenanceResourceSyncing means the replacement replicas are not yet UpToDate.
+	NodeMaintenanceResourceSyncing = "Syncing"
+	// NodeMaintenanceResourceRemoving means the replica on the node is being removed.
+	NodeMaintenanceResourceRemoving = "Removing"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LinstorNodeMaintenance is the Schema for the linstornodemaintenances API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=linstornodemaintenances,scope=Namespaced
+// +kubebuilder:printcolumn:name="Node",type="string",JSONPath=".spec.nodeName"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:storageversion
+type LinstorNodeMaintenance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LinstorNodeMaintenanceSpec   `json:"spec,omitempty"`
+	Status LinstorNodeMaintenanceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LinstorNodeMaintenanceList contains a list of LinstorNodeMaintenance
+type LinstorNodeMaintenanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LinstorNodeMaintenance `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LinstorNodeMaintenance{}, &LinstorNodeMaintenanceList{})
+}
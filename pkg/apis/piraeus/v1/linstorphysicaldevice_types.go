@@ -0,0 +1,90 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LinstorPhysicalDeviceSpec identifies the device. It is set by the operator and should not be changed.
+type LinstorPhysicalDeviceSpec struct {
+	// NodeName is the name of the node the device is attached to.
+	NodeName string `json:"nodeName"`
+	// DevicePath is the path of the device on the node, as reported by LINSTOR.
+	DevicePath string `json:"devicePath"`
+}
+
+// LinstorPhysicalDeviceStatus reports the properties of the device, as last reported by LINSTOR.
+type LinstorPhysicalDeviceStatus struct {
+	// Size of the device.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+	// Rotational is true for spinning disks.
+	// +optional
+	Rotational bool `json:"rotational,omitempty"`
+	// Model of the device.
+	// +optional
+	Model string `json:"model,omitempty"`
+	// Serial number of the device.
+	// +optional
+	Serial string `json:"serial,omitempty"`
+	// WWN is the world wide name of the device.
+	// +optional
+	WWN string `json:"wwn,omitempty"`
+	// ClaimedBy is the name of the storage pool using the device, as configured in the LinstorSatelliteSet.
+	// +optional
+	ClaimedBy string `json:"claimedBy,omitempty"`
+	// Available is true if LINSTOR reports the device as empty, so it can be used for a new storage pool.
+	Available bool `json:"available"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LinstorPhysicalDevice is a storage device found on a satellite, which can be used for storage pools. The resource is
+// managed by the operator, changes will be reverted.
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=linstorphysicaldevices,scope=Namespaced
+// +kubebuilder:printcolumn:name="Node",type="string",JSONPath=".spec.nodeName"
+// +kubebuilder:printcolumn:name="Device",type="string",JSONPath=".spec.devicePath"
+// +kubebuilder:printcolumn:name="Size",type="string",JSONPath=".status.size"
+// +kubebuilder:printcolumn:name="Rotational",type="boolean",JSONPath=".status.rotational"
+// +kubebuilder:printcolumn:name="Model",type="string",JSONPath=".status.model"
+// +kubebuilder:printcolumn:name="Available",type="boolean",JSONPath=".status.available"
+// +kubebuilder:printcolumn:name="Claimed By",type="string",JSONPath=".status.claimedBy"
+// +kubebuilder:storageversion
+type LinstorPhysicalDevice struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LinstorPhysicalDeviceSpec   `json:"spec,omitempty"`
+	Status LinstorPhysicalDeviceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// LinstorPhysicalDeviceList contains a list of LinstorPhysicalDevice
+type LinstorPhysicalDeviceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LinstorPhysicalDevice `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&LinstorPhysicalDevice{}, &LinstorPhysicalDeviceList{})
+}
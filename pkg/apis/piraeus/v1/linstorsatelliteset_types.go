@@ -35,19 +35,101 @@ type LinstorSatelliteSetSpec struct {
 
 	// If set, the operator will automatically create storage pools of the specified type for all devices that can
 	// be found. The name of the storage pools matches the device name. For example, all devices `/dev/sdc` will be
-	// part of the `autopool-sdc` storage pool.
+	// part of the `autopool-sdc` storage pool. Use AutomaticStorageDevices to select devices and pool names.
 	// Note: Using this attribute is discouraged. Using the "storagePools" to set up devices allows for more control on
 	// device creation.
 	// +optional
 	// +kubebuilder:validation:Enum=None;LVM;LVMTHIN;ZFS
 	AutomaticStorageType string `json:"automaticStorageType"`
 
+	// AutomaticStorageDevices selects the devices used with AutomaticStorageType, and how they are grouped into
+	// storage pools.
+	// +optional
+	// +nullable
+	AutomaticStorageDevices *shared.AutomaticStorageDevices `json:"automaticStorageDevices"`
+
+	// StoragePoolMigrationPolicy determines what happens to storage pools that no longer match the spec, for example
+	// because the provider or driver properties changed. Empty pools are always deleted and recreated. With "Manual",
+	// pools containing resources are reported as pending migration until all resources are removed. With
+	// "Evacuate", the operator moves the resources to other storage pools first.
+	// +optional
+	// +kubebuilder:validation:Enum=Manual;Evacuate
+	StoragePoolMigrationPolicy shared.StoragePoolMigrationPolicy `json:"storagePoolMigrationPolicy"`
+
+	// StoragePoolRemovalPolicy determines what happens to storage pools that were removed from the spec. With
+	// "Manual", pools are only deleted once they no longer contain resources. With "Drain", the operator moves the
+	// resources to the remaining storage pools first.
+	// +optional
+	// +kubebuilder:validation:Enum=Manual;Drain
+	StoragePoolRemovalPolicy shared.StoragePoolRemovalPolicy `json:"storagePoolRemovalPolicy"`
+
+	// DanglingSatellitePolicy determines what happens to satellites registered by the operator, whose kubernetes
+	// node no longer exists. With "Evict", the satellite is evicted, so LINSTOR moves resources to other nodes. With
+	// "EvictAndLost", the satellite is removed from LINSTOR after the eviction. With "ReportOnly", the satellite is
+	// only reported in the status.
+	// +optional
+	// +kubebuilder:validation:Enum=Evict;EvictAndLost;ReportOnly
+	DanglingSatellitePolicy shared.DanglingSatellitePolicy `json:"danglingSatellitePolicy"`
+
+	// DanglingSatelliteGracePeriod is the time to wait for a removed kubernetes node to come back, before the
+	// DanglingSatellitePolicy is applied.
+	// +optional
+	// +nullable
+	DanglingSatelliteGracePeriod *metav1.Duration `json:"danglingSatelliteGracePeriod"`
+
+	// NetInterfaces are additional network interfaces registered on every satellite, for example to use a dedicated
+	// network for DRBD replication.
+	// +optional
+	// +nullable
+	NetInterfaces []*shared.SatelliteNetInterface `json:"netInterfaces"`
+
+	// PrefNic is the name of the network interface LINSTOR should prefer for DRBD replication on every satellite.
+	// Must be "default", the name of one of the NetInterfaces or an interface registered for IPFamilies. Storage
+	// pools may override it using the "PrefNic" property.
+	// +optional
+	PrefNic string `json:"prefNic"`
+
+	// IPFamilies selects the address families used to register the satellites in LINSTOR. The address of the first
+	// family is used for the "default" interface, additional families are registered as "default-ipv4" or
+	// "default-ipv6". If not set, the primary node address is used.
+	// +optional
+	// +nullable
+	// +kubebuilder:validation:MaxItems=2
+	IPFamilies []shared.IPFamily `json:"ipFamilies"`
+
+	// NodeLabelSync selects the kubernetes node labels copied to LINSTOR as auxiliary node properties. If not set,
+	// all labels are copied.
+	// +optional
+	// +nullable
+	NodeLabelSync *shared.NodeLabelSync `json:"nodeLabelSync"`
+
+	// AdditionalProperties is a map of additional properties to set on every satellite.
+	// +optional
+	// +nullable
+	AdditionalProperties map[string]string `json:"additionalProperties"`
+
+	// NodeProperties sets additional properties on satellites running on selected nodes. Properties of later entries
+	// take precedence over those of earlier entries and AdditionalProperties.
+	// +optional
+	// +nullable
+	NodeProperties []*shared.NodeProperties `json:"nodeProperties"`
+
+	// UpdateStrategy determines how changes to the satellite pods are rolled out.
+	// +optional
+	// +nullable
+	UpdateStrategy *SatelliteUpdateStrategy `json:"updateStrategy"`
+
 	// Name of k8s secret that holds the SSL key for a node (called `keystore.jks`) and
 	// the trusted certificates (called `certificates.jks`)
 	// +optional
 	// +nullable
 	SslConfig *shared.LinstorSSLConfig `json:"sslSecret"`
 
+	// SslOptions configure the TLS protocol, cipher suites and keystore passwords used with `sslSecret`
+	// +optional
+	// +nullable
+	SslOptions *shared.LinstorSSLOptions `json:"sslOptions"`
+
 	// drbdRepoCred is the name of the kubernetes secret that holds the credential for the DRBD repositories
 	DrbdRepoCred string `json:"drbdRepoCred"`
 
@@ -72,6 +154,13 @@ type LinstorSatelliteSetSpec struct {
 	// +optional
 	KernelModuleInjectionImage string `json:"kernelModuleInjectionImage"`
 
+	// KernelModuleInjectionImages selects the kernel module injector image for nodes based on their operating system
+	// and kernel version. For every entry, a separate satellite DaemonSet is created. The first matching entry is
+	// used. Nodes without matching entry use the KernelModuleInjectionImage.
+	// +optional
+	// +nullable
+	KernelModuleInjectionImages []*shared.KernelModuleInjectionImage `json:"kernelModuleInjectionImages"`
+
 	// kernelModuleInjectionMode selects the source for the DRBD kernel module
 	// +kubebuilder:validation:Enum=None;Compile;ShippedModules;DepsOnly
 	// +optional
@@ -82,6 +171,32 @@ type LinstorSatelliteSetSpec struct {
 	// +nullable
 	KernelModuleInjectionResources corev1.ResourceRequirements `json:"kernelModuleInjectionResources"`
 
+	// NodeOverrides change the container resources and JVM options of satellites on nodes matching their node
+	// selector. The first matching entry applies to a node. Every entry gets its own satellite DaemonSet.
+	// +optional
+	// +nullable
+	NodeOverrides []*shared.SatelliteNodeOverride `json:"nodeOverrides"`
+
+	// PreflightPolicy determines how host preflight checks are run before the satellite starts. "None" disables the
+	// checks, "Report" reports the results in the satellite status, "Block" additionally prevents the satellite from
+	// starting if a check failed.
+	// +kubebuilder:validation:Enum=None;Report;Block
+	// +optional
+	PreflightPolicy shared.PreflightPolicy `json:"preflightPolicy"`
+
+	// ResourceHealthDetailLimit is the maximum number of degraded resources listed per satellite in the status. The
+	// resource health counters are always reported. Defaults to 10.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	// +nullable
+	ResourceHealthDetailLimit *int32 `json:"resourceHealthDetailLimit"`
+
+	// NodeSelector restricts the satellite pods to nodes with matching labels. Every node may only be selected by one
+	// LinstorSatelliteSet using the same controller.
+	// +optional
+	// +nullable
+	NodeSelector map[string]string `json:"nodeSelector"`
+
 	// Affinity for scheduling the satellite pods
 	// +optional
 	// +nullable
@@ -106,6 +221,18 @@ type LinstorSatelliteSetSpec struct {
 	// +nullable
 	MonitoringImage string `json:"monitoringImage"`
 
+	// MonitoringProxy serves the metrics exported by MonitoringImage via HTTPS, using a kube-rbac-proxy sidecar.
+	// Clients need a bearer token that is allowed to "get" the "/metrics" non-resource URL.
+	// +optional
+	// +nullable
+	MonitoringProxy *MonitoringProxySpec `json:"monitoringProxy"`
+
+	// DrbdReactor configures additional drbd-reactor plugins, running next to the satellite. The prometheus plugin
+	// is configured by setting MonitoringImage.
+	// +optional
+	// +nullable
+	DrbdReactor *DrbdReactorSpec `json:"drbdReactor"`
+
 	shared.LinstorClientConfig `json:",inline"`
 }
 
@@ -115,6 +242,208 @@ type LinstorSatelliteSetStatus struct {
 	Errors []string `json:"errors"`
 	// SatelliteStatuses by hostname.
 	SatelliteStatuses []*shared.SatelliteStatus `json:"SatelliteStatuses"`
+	// DanglingSatellites are satellites registered by the operator, whose kubernetes node no longer exists.
+	// +optional
+	// +nullable
+	DanglingSatellites []*DanglingSatelliteStatus `json:"danglingSatellites"`
+	// NodesWithoutInjectionImage are nodes not matching any of the KernelModuleInjectionImages. They use the
+	// KernelModuleInjectionImage instead.
+	// +optional
+	// +nullable
+	NodesWithoutInjectionImage []string `json:"nodesWithoutInjectionImage"`
+	// Rollout reports the progress of replacing satellite pods with the "Managed" update strategy.
+	// +optional
+	// +nullable
+	Rollout *SatelliteRolloutStatus `json:"rollout"`
+}
+
+// DanglingSatelliteStatus reports a satellite pending removal.
+type DanglingSatelliteStatus struct {
+	// The name of the satellite and the removed kubernetes node.
+	NodeName string `json:"nodeName"`
+	// Since is the time the kubernetes node was first found missing.
+	Since metav1.Time `json:"since"`
+	// Phase of the removal. One of "Pending", "Reported", "Evicted" or "Online".
+	Phase string `json:"phase"`
+}
+
+const (
+	// DanglingSatellitePending means the grace period did not expire yet.
+	DanglingSatellitePending = "Pending"
+	// DanglingSatelliteReported means the satellite needs to be removed manually.
+	DanglingSatelliteReported = "Reported"
+	// DanglingSatelliteEvicted means the satellite was evicted, but not removed from LINSTOR.
+	DanglingSatelliteEvicted = "Evicted"
+	// DanglingSatelliteOnline means the satellite is still connected, so it can't be removed.
+	DanglingSatelliteOnline = "Online"
+)
+
+// SatelliteUpdateStrategyType selects how satellite pods are replaced.
+type SatelliteUpdateStrategyType string
+
+const (
+	// SatelliteUpdateRollingUpdate leaves replacing satellite pods to the DaemonSet controller.
+	SatelliteUpdateRollingUpdate SatelliteUpdateStrategyType = "RollingUpdate"
+	// SatelliteUpdateManaged lets the operator replace satellite pods node by node.
+	SatelliteUpdateManaged SatelliteUpdateStrategyType = "Managed"
+)
+
+// SatelliteUpdateStrategy determines how changes to the satellite pods are rolled out.
+type SatelliteUpdateStrategy struct {
+	// Type of the update strategy. With "RollingUpdate", the DaemonSet replaces all satellite pods at once. With
+	// "Managed", the operator replaces satellite pods node by node, waiting for the replaced satellite to be online
+	// again before continuing with the next node. A satellite is only replaced once all DRBD resources with a replica
+	// on its node are UpToDate and connected.
+	// +optional
+	// +kubebuilder:validation:Enum=RollingUpdate;Managed
+	Type SatelliteUpdateStrategyType `json:"type"`
+
+	// MaxUnavailable is the number of satellites that may be unavailable during a "Managed" update. A satellite is
+	// unavailable while its pod is missing, not ready or being deleted, or it is not online in LINSTOR. Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxUnavailable int32 `json:"maxUnavailable"`
+
+	// Paused stops the operator from replacing further satellite pods during a "Managed" update.
+	// +optional
+	Paused bool `json:"paused"`
+}
+
+// MonitoringProxySpec configures the TLS proxy in front of the metrics endpoint.
+type MonitoringProxySpec struct {
+	// Image is the kube-rbac-proxy image.
+	Image string `json:"image"`
+
+	// TLSSecret is the name of the k8s secret holding the certificate (`tls.crt`), the key (`tls.key`) and the CA
+	// (`ca.crt`) of the metrics endpoint. The certificate needs to be valid for the monitoring service, i.e.
+	// `<name>-monitoring.<namespace>.svc`.
+	TLSSecret string `json:"tlsSecret"`
+}
+
+// DrbdReactorSpec configures the drbd-reactor plugins besides prometheus.
+type DrbdReactorSpec struct {
+	// Image is the drbd-reactor image, used if MonitoringImage is not set.
+	// +optional
+	Image string `json:"image"`
+
+	// Promoter plugins promote a DRBD resource on one node and start services depending on it.
+	// +optional
+	// +nullable
+	Promoter []*DrbdReactorPromoter `json:"promoter"`
+
+	// UMH plugins run commands on changes to DRBD resources, devices, peer devices or connections.
+	// +optional
+	// +nullable
+	UMH []*DrbdReactorUMH `json:"umh"`
+
+	// AgentX plugins export DRBD state via an SNMP AgentX master.
+	// +optional
+	// +nullable
+	AgentX []*DrbdReactorAgentX `json:"agentx"`
+
+	// ExtraConfig is additional drbd-reactor configuration in TOML format, for settings not covered above.
+	// +optional
+	ExtraConfig string `json:"extraConfig"`
+}
+
+// DrbdReactorPromoter configures a promoter plugin.
+type DrbdReactorPromoter struct {
+	// Resources maps the names of DRBD resources to the services started on the node promoting the resource.
+	Resources map[string]*DrbdReactorPromoterResource `json:"resources"`
+}
+
+// DrbdReactorPromoterResource configures the services started for a DRBD resource.
+type DrbdReactorPromoterResource struct {
+	// Start is the list of services started, in order, once the resource is promoted.
+	Start []string `json:"start"`
+
+	// Runner used to start the services. The "shell" runner executes the services as commands.
+	// +optional
+	// +kubebuilder:validation:Enum=systemd;shell
+	Runner string `json:"runner"`
+
+	// OnDrbdDemoteFailure is the action taken if the resource can't be demoted, for example "reboot".
+	// +optional
+	OnDrbdDemoteFailure string `json:"onDrbdDemoteFailure"`
+
+	// StopServicesOnExit stops the services when drbd-reactor exits.
+	// +optional
+	StopServicesOnExit bool `json:"stopServicesOnExit"`
+}
+
+// DrbdReactorUMH configures a UMH plugin.
+type DrbdReactorUMH struct {
+	// Rules select the events and the commands run for them.
+	Rules []*DrbdReactorUMHRule `json:"rules"`
+}
+
+// DrbdReactorUMHRule runs a command for matching events.
+type DrbdReactorUMHRule struct {
+	// Type of the DRBD object the rule matches.
+	// +kubebuilder:validation:Enum=resource;device;peerdevice;connection
+	Type string `json:"type"`
+
+	// Name of the rule, used in log messages.
+	Name string `json:"name"`
+
+	// Command run for every matching event.
+	Command string `json:"command"`
+
+	// ResourceName restricts the rule to a DRBD resource.
+	// +optional
+	ResourceName string `json:"resourceName"`
+
+	// EventType restricts the rule to an event type, for example "Change".
+	// +optional
+	EventType string `json:"eventType"`
+
+	// Old matches the state before the event, for example {"role": "Primary"}. Only string values are supported, use
+	// ExtraConfig for other filters.
+	// +optional
+	// +nullable
+	Old map[string]string `json:"old"`
+
+	// New matches the state after the event, for example {"role": "Secondary"}. Only string values are supported,
+	// use ExtraConfig for other filters.
+	// +optional
+	// +nullable
+	New map[string]string `json:"new"`
+}
+
+// DrbdReactorAgentX configures an AgentX plugin.
+type DrbdReactorAgentX struct {
+	// Address of the AgentX master.
+	Address string `json:"address"`
+
+	// CacheMax is the time in seconds DRBD state is cached.
+	// +optional
+	CacheMax int32 `json:"cacheMax"`
+
+	// AgentTimeout is the timeout in seconds for the connection to the AgentX master.
+	// +optional
+	AgentTimeout int32 `json:"agentTimeout"`
+
+	// PeerStates exports the states of the peers.
+	// +optional
+	PeerStates bool `json:"peerStates"`
+}
+
+// SatelliteRolloutStatus reports the progress of a "Managed" satellite update.
+type SatelliteRolloutStatus struct {
+	// UpdatedPods is the number of satellite pods running the current pod template.
+	UpdatedPods int32 `json:"updatedPods"`
+	// TotalPods is the number of satellite pods.
+	TotalPods int32 `json:"totalPods"`
+	// Paused is set if the update is paused.
+	Paused bool `json:"paused"`
+	// UnavailableNodes are the nodes with an unavailable satellite, blocking further updates.
+	// +optional
+	// +nullable
+	UnavailableNodes []string `json:"unavailableNodes"`
+	// BlockingResources are the resources not in sync, blocking updates of the nodes with a replica of them.
+	// +optional
+	// +nullable
+	BlockingResources []string `json:"blockingResources"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -24,9 +24,201 @@ package v1
 import (
 	shared "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DanglingSatelliteStatus) DeepCopyInto(out *DanglingSatelliteStatus) {
+	*out = *in
+	in.Since.DeepCopyInto(&out.Since)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DanglingSatelliteStatus.
+func (in *DanglingSatelliteStatus) DeepCopy() *DanglingSatelliteStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DanglingSatelliteStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrbdReactorAgentX) DeepCopyInto(out *DrbdReactorAgentX) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrbdReactorAgentX.
+func (in *DrbdReactorAgentX) DeepCopy() *DrbdReactorAgentX {
+	if in == nil {
+		return nil
+	}
+	out := new(DrbdReactorAgentX)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrbdReactorPromoter) DeepCopyInto(out *DrbdReactorPromoter) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make(map[string]*DrbdReactorPromoterResource, len(*in))
+		for key, val := range *in {
+			var outVal *DrbdReactorPromoterResource
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(DrbdReactorPromoterResource)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrbdReactorPromoter.
+func (in *DrbdReactorPromoter) DeepCopy() *DrbdReactorPromoter {
+	if in == nil {
+		return nil
+	}
+	out := new(DrbdReactorPromoter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrbdReactorPromoterResource) DeepCopyInto(out *DrbdReactorPromoterResource) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrbdReactorPromoterResource.
+func (in *DrbdReactorPromoterResource) DeepCopy() *DrbdReactorPromoterResource {
+	if in == nil {
+		return nil
+	}
+	out := new(DrbdReactorPromoterResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrbdReactorSpec) DeepCopyInto(out *DrbdReactorSpec) {
+	*out = *in
+	if in.Promoter != nil {
+		in, out := &in.Promoter, &out.Promoter
+		*out = make([]*DrbdReactorPromoter, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DrbdReactorPromoter)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.UMH != nil {
+		in, out := &in.UMH, &out.UMH
+		*out = make([]*DrbdReactorUMH, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DrbdReactorUMH)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.AgentX != nil {
+		in, out := &in.AgentX, &out.AgentX
+		*out = make([]*DrbdReactorAgentX, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DrbdReactorAgentX)
+				**out = **in
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrbdReactorSpec.
+func (in *DrbdReactorSpec) DeepCopy() *DrbdReactorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DrbdReactorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrbdReactorUMH) DeepCopyInto(out *DrbdReactorUMH) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]*DrbdReactorUMHRule, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DrbdReactorUMHRule)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrbdReactorUMH.
+func (in *DrbdReactorUMH) DeepCopy() *DrbdReactorUMH {
+	if in == nil {
+		return nil
+	}
+	out := new(DrbdReactorUMH)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DrbdReactorUMHRule) DeepCopyInto(out *DrbdReactorUMHRule) {
+	*out = *in
+	if in.Old != nil {
+		in, out := &in.Old, &out.Old
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.New != nil {
+		in, out := &in.New, &out.New
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DrbdReactorUMHRule.
+func (in *DrbdReactorUMHRule) DeepCopy() *DrbdReactorUMHRule {
+	if in == nil {
+		return nil
+	}
+	out := new(DrbdReactorUMHRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LinstorCSIDriver) DeepCopyInto(out *LinstorCSIDriver) {
 	*out = *in
@@ -225,6 +417,11 @@ func (in *LinstorControllerSpec) DeepCopyInto(out *LinstorControllerSpec) {
 		*out = new(shared.LinstorSSLConfig)
 		**out = **in
 	}
+	if in.SslOptions != nil {
+		in, out := &in.SslOptions, &out.SslOptions
+		*out = new(shared.LinstorSSLOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Resources.DeepCopyInto(&out.Resources)
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
@@ -257,6 +454,11 @@ func (in *LinstorControllerSpec) DeepCopyInto(out *LinstorControllerSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]shared.IPFamily, len(*in))
+		copy(*out, *in)
+	}
 	out.LinstorClientConfig = in.LinstorClientConfig
 	return
 }
@@ -315,6 +517,214 @@ func (in *LinstorControllerStatus) DeepCopy() *LinstorControllerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorNodeMaintenance) DeepCopyInto(out *LinstorNodeMaintenance) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorNodeMaintenance.
+func (in *LinstorNodeMaintenance) DeepCopy() *LinstorNodeMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorNodeMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LinstorNodeMaintenance) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorNodeMaintenanceList) DeepCopyInto(out *LinstorNodeMaintenanceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LinstorNodeMaintenance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorNodeMaintenanceList.
+func (in *LinstorNodeMaintenanceList) DeepCopy() *LinstorNodeMaintenanceList {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorNodeMaintenanceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LinstorNodeMaintenanceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorNodeMaintenanceSpec) DeepCopyInto(out *LinstorNodeMaintenanceSpec) {
+	*out = *in
+	out.LinstorClientConfig = in.LinstorClientConfig
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorNodeMaintenanceSpec.
+func (in *LinstorNodeMaintenanceSpec) DeepCopy() *LinstorNodeMaintenanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorNodeMaintenanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorNodeMaintenanceStatus) DeepCopyInto(out *LinstorNodeMaintenanceStatus) {
+	*out = *in
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]*NodeMaintenanceResourceStatus, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(NodeMaintenanceResourceStatus)
+				**out = **in
+			}
+		}
+	}
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorNodeMaintenanceStatus.
+func (in *LinstorNodeMaintenanceStatus) DeepCopy() *LinstorNodeMaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorNodeMaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorPhysicalDevice) DeepCopyInto(out *LinstorPhysicalDevice) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorPhysicalDevice.
+func (in *LinstorPhysicalDevice) DeepCopy() *LinstorPhysicalDevice {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorPhysicalDevice)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LinstorPhysicalDevice) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorPhysicalDeviceList) DeepCopyInto(out *LinstorPhysicalDeviceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LinstorPhysicalDevice, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorPhysicalDeviceList.
+func (in *LinstorPhysicalDeviceList) DeepCopy() *LinstorPhysicalDeviceList {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorPhysicalDeviceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LinstorPhysicalDeviceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorPhysicalDeviceSpec) DeepCopyInto(out *LinstorPhysicalDeviceSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorPhysicalDeviceSpec.
+func (in *LinstorPhysicalDeviceSpec) DeepCopy() *LinstorPhysicalDeviceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorPhysicalDeviceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LinstorPhysicalDeviceStatus) DeepCopyInto(out *LinstorPhysicalDeviceStatus) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LinstorPhysicalDeviceStatus.
+func (in *LinstorPhysicalDeviceStatus) DeepCopy() *LinstorPhysicalDeviceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LinstorPhysicalDeviceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LinstorSatelliteSet) DeepCopyInto(out *LinstorSatelliteSet) {
 	*out = *in
@@ -384,13 +794,46 @@ func (in *LinstorSatelliteSetSpec) DeepCopyInto(out *LinstorSatelliteSetSpec) {
 		*out = new(shared.StoragePools)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AutomaticStorageDevices != nil {
+		in, out := &in.AutomaticStorageDevices, &out.AutomaticStorageDevices
+		*out = new(shared.AutomaticStorageDevices)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SslConfig != nil {
 		in, out := &in.SslConfig, &out.SslConfig
 		*out = new(shared.LinstorSSLConfig)
 		**out = **in
 	}
+	if in.SslOptions != nil {
+		in, out := &in.SslOptions, &out.SslOptions
+		*out = new(shared.LinstorSSLOptions)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Resources.DeepCopyInto(&out.Resources)
 	in.KernelModuleInjectionResources.DeepCopyInto(&out.KernelModuleInjectionResources)
+	if in.NodeOverrides != nil {
+		in, out := &in.NodeOverrides, &out.NodeOverrides
+		*out = make([]*shared.SatelliteNodeOverride, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(shared.SatelliteNodeOverride)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.ResourceHealthDetailLimit != nil {
+		in, out := &in.ResourceHealthDetailLimit, &out.ResourceHealthDetailLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Affinity != nil {
 		in, out := &in.Affinity, &out.Affinity
 		*out = new(corev1.Affinity)
@@ -410,6 +853,76 @@ func (in *LinstorSatelliteSetSpec) DeepCopyInto(out *LinstorSatelliteSetSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.DanglingSatelliteGracePeriod != nil {
+		in, out := &in.DanglingSatelliteGracePeriod, &out.DanglingSatelliteGracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.NetInterfaces != nil {
+		in, out := &in.NetInterfaces, &out.NetInterfaces
+		*out = make([]*shared.SatelliteNetInterface, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(shared.SatelliteNetInterface)
+				**out = **in
+			}
+		}
+	}
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]shared.IPFamily, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodeLabelSync != nil {
+		in, out := &in.NodeLabelSync, &out.NodeLabelSync
+		*out = new(shared.NodeLabelSync)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalProperties != nil {
+		in, out := &in.AdditionalProperties, &out.AdditionalProperties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.NodeProperties != nil {
+		in, out := &in.NodeProperties, &out.NodeProperties
+		*out = make([]*shared.NodeProperties, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(shared.NodeProperties)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.KernelModuleInjectionImages != nil {
+		in, out := &in.KernelModuleInjectionImages, &out.KernelModuleInjectionImages
+		*out = make([]*shared.KernelModuleInjectionImage, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(shared.KernelModuleInjectionImage)
+				**out = **in
+			}
+		}
+	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(SatelliteUpdateStrategy)
+		**out = **in
+	}
+	if in.MonitoringProxy != nil {
+		in, out := &in.MonitoringProxy, &out.MonitoringProxy
+		*out = new(MonitoringProxySpec)
+		**out = **in
+	}
+	if in.DrbdReactor != nil {
+		in, out := &in.DrbdReactor, &out.DrbdReactor
+		*out = new(DrbdReactorSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	out.LinstorClientConfig = in.LinstorClientConfig
 	return
 }
@@ -443,6 +956,27 @@ func (in *LinstorSatelliteSetStatus) DeepCopyInto(out *LinstorSatelliteSetStatus
 			}
 		}
 	}
+	if in.DanglingSatellites != nil {
+		in, out := &in.DanglingSatellites, &out.DanglingSatellites
+		*out = make([]*DanglingSatelliteStatus, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(DanglingSatelliteStatus)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.NodesWithoutInjectionImage != nil {
+		in, out := &in.NodesWithoutInjectionImage, &out.NodesWithoutInjectionImage
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(SatelliteRolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -455,3 +989,77 @@ func (in *LinstorSatelliteSetStatus) DeepCopy() *LinstorSatelliteSetStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringProxySpec) DeepCopyInto(out *MonitoringProxySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringProxySpec.
+func (in *MonitoringProxySpec) DeepCopy() *MonitoringProxySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringProxySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMaintenanceResourceStatus) DeepCopyInto(out *NodeMaintenanceResourceStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMaintenanceResourceStatus.
+func (in *NodeMaintenanceResourceStatus) DeepCopy() *NodeMaintenanceResourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMaintenanceResourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SatelliteRolloutStatus) DeepCopyInto(out *SatelliteRolloutStatus) {
+	*out = *in
+	if in.UnavailableNodes != nil {
+		in, out := &in.UnavailableNodes, &out.UnavailableNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlockingResources != nil {
+		in, out := &in.BlockingResources, &out.BlockingResources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SatelliteRolloutStatus.
+func (in *SatelliteRolloutStatus) DeepCopy() *SatelliteRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SatelliteRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SatelliteUpdateStrategy) DeepCopyInto(out *SatelliteUpdateStrategy) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SatelliteUpdateStrategy.
+func (in *SatelliteUpdateStrategy) DeepCopy() *SatelliteUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(SatelliteUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -171,6 +171,13 @@ func (r *ReconcileLinstorController) reconcileSpec(ctx context.Context, controll
 		return fmt.Errorf("failed to add finalizer: %w", err)
 	}
 
+	log.Debug("validate TLS options")
+
+	err = controllerResource.Spec.SslOptions.Validate()
+	if err != nil {
+		return err
+	}
+
 	log.Debug("reconcile LINSTOR Service")
 
 	ctrlService := newServiceForResource(controllerResource)
@@ -277,11 +284,36 @@ func (r *ReconcileLinstorController) reconcileControllers(ctx context.Context, c
 		}
 	}
 
+	sslProps, err := r.sslConnectorProps(ctx, controllerResource)
+	if err != nil {
+		return err
+	}
+
+	sslChanged := false
+
+	for k, v := range sslProps {
+		existing, ok := allProperties[k]
+		if !ok || existing != v {
+			modify.OverrideProps[k] = v
+			sslChanged = true
+		}
+	}
+
 	err = linstorClient.Controller.Modify(ctx, modify)
 	if err != nil {
 		return fmt.Errorf("could not reconcile additional properties: %w", err)
 	}
 
+	if sslChanged {
+		// LINSTOR only reads the TLS configuration for satellite connections on startup.
+		log.Info("TLS options for satellite connections changed, restart LINSTOR Controller")
+
+		err := reconcileutil.RestartRollout(ctx, r.client, newDeploymentForResource(controllerResource))
+		if err != nil {
+			return fmt.Errorf("failed to restart LINSTOR Controller after TLS options change: %w", err)
+		}
+	}
+
 	log.Debug("find existing controller nodes")
 	allNodes, err := linstorClient.Nodes.GetAll(ctx)
 	if err != nil {
@@ -307,18 +339,16 @@ func (r *ReconcileLinstorController) reconcileControllers(ctx context.Context, c
 
 	for _, pod := range ourPods.Items {
 		log.WithField("pod", pod.Name).Debug("register controller pod")
-		_, err := linstorClient.GetNodeOrCreate(ctx, lapi.Node{
-			Name: pod.Name,
-			Type: lc.Controller,
-			NetInterfaces: []lapi.NetInterface{
-				{
-					Name:                    "default",
-					Address:                 pod.Status.PodIP,
-					IsActive:                true,
-					SatellitePort:           controllerResource.Spec.SslConfig.Port(),
-					SatelliteEncryptionType: controllerResource.Spec.SslConfig.Type(),
-				},
-			},
+
+		netInterfaces, err := lc.DefaultNetInterfaces(lc.PodAddresses(&pod), controllerResource.Spec.IPFamilies, controllerResource.Spec.SslConfig)
+		if err != nil {
+			return fmt.Errorf("failed to determine address of controller pod '%s': %w", pod.Name, err)
+		}
+
+		_, err = linstorClient.GetNodeOrCreate(ctx, lapi.Node{
+			Name:          pod.Name,
+			Type:          lc.Controller,
+			NetInterfaces: netInterfaces,
 			Props: map[string]string{
 				kubeSpec.LinstorRegistrationProperty: kubeSpec.Name,
 			},
@@ -586,6 +616,26 @@ func (r *ReconcileLinstorController) deleteFinalizer(ctx context.Context, contro
 	return nil
 }
 
+// sslConnectorProps returns the controller properties configuring the TLS connections to satellites. Returns nil if
+// satellites are connected without TLS.
+func (r *ReconcileLinstorController) sslConnectorProps(ctx context.Context, controllerResource *piraeusv1.LinstorController) (map[string]string, error) {
+	if controllerResource.Spec.SslConfig.IsPlain() {
+		return nil, nil
+	}
+
+	passwords, err := controllerResource.Spec.SslOptions.Passwords(lc.NamedSecret(ctx, r.client, controllerResource.Namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		kubeSpec.LinstorSslConnectorKeystorePasswordProperty:   passwords.Keystore,
+		kubeSpec.LinstorSslConnectorKeyPasswordProperty:        passwords.Key,
+		kubeSpec.LinstorSslConnectorTruststorePasswordProperty: passwords.Truststore,
+		kubeSpec.LinstorSslConnectorProtocolProperty:           controllerResource.Spec.SslOptions.GetProtocol(),
+	}, nil
+}
+
 // Check if the controller is currently reachable.
 func (r *ReconcileLinstorController) controllerReachable(ctx context.Context, linstorClient *lc.HighLevelClient) error {
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -618,11 +668,17 @@ func newDeploymentForResource(controllerResource *piraeusv1.LinstorController) *
 		panic(err)
 	}
 
+	// Workaround for https://github.com/LINBIT/linstor-server/issues/123
+	javaOpts := "-Djdk.tls.acknowledgeCloseNotify=true"
+
+	if sslOpts := controllerResource.Spec.SslOptions.JavaOpts(); sslOpts != "" && !controllerResource.Spec.SslConfig.IsPlain() {
+		javaOpts += " " + sslOpts
+	}
+
 	env := []corev1.EnvVar{
 		{
-			Name: kubeSpec.JavaOptsName,
-			// Workaround for https://github.com/LINBIT/linstor-server/issues/123
-			Value: "-Djdk.tls.acknowledgeCloseNotify=true",
+			Name:  kubeSpec.JavaOptsName,
+			Value: javaOpts,
 		},
 		{
 			Name:  awaitelection.AwaitElectionEnabledKey,
@@ -0,0 +1,522 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstornodemaintenance
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	mdutil "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/metadata/util"
+	"github.com/piraeusdatastore/piraeus-operator/pkg/k8s/reconcileutil"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+const (
+	// linstorNodeMaintenanceFinalizer ensures the node is restored before the resource is removed.
+	linstorNodeMaintenanceFinalizer = "finalizer.linstor-node-maintenance.linbit.com"
+
+	// requeue reconciliation after retrySeconds
+	retrySeconds = 10
+
+	// Set by the kubelet on static pods, which can't be evicted.
+	mirrorPodAnnotation = "kubernetes.io/config.mirror"
+)
+
+func init() {
+	logrus.SetFormatter(&logrus.TextFormatter{})
+	logrus.SetOutput(os.Stdout)
+	logrus.SetLevel(logrus.DebugLevel)
+}
+
+var log = logrus.WithFields(logrus.Fields{
+	"controller": "LinstorNodeMaintenance",
+})
+
+// Add creates a new LinstorNodeMaintenance Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	r, err := newReconciler(mgr)
+	if err != nil {
+		return err
+	}
+
+	return add(mgr, r)
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
+	// The controller-runtime client can't create evictions, and may be restricted to a single namespace, so we use a
+	// plain clientset to drain pods.
+	clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReconcileLinstorNodeMaintenance{client: mgr.GetClient(), clientset: clientset, scheme: mgr.GetScheme()}, nil
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("linstornodemaintenance-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource LinstorNodeMaintenance
+	return c.Watch(&source.Kind{Type: &piraeusv1.LinstorNodeMaintenance{}}, &handler.EnqueueRequestForObject{})
+}
+
+// blank assignment to verify that ReconcileLinstorNodeMaintenance implements reconcile.Reconciler
+var _ reconcile.Reconciler = &ReconcileLinstorNodeMaintenance{}
+
+// ReconcileLinstorNodeMaintenance reconciles a LinstorNodeMaintenance object
+type ReconcileLinstorNodeMaintenance struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client    client.Client
+	clientset kubernetes.Interface
+	scheme    *runtime.Scheme
+}
+
+// Reconcile prepares the referenced node for maintenance: the node is cordoned, LINSTOR resources are moved to
+// other nodes and pods are evicted. Once the LinstorNodeMaintenance resource is deleted, the node is restored.
+func (r *ReconcileLinstorNodeMaintenance) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := log.WithFields(logrus.Fields{
+		"requestName":      request.Name,
+		"requestNamespace": request.Namespace,
+	})
+	log.Info("reconciling LinstorNodeMaintenance")
+
+	ctx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+	defer cancel()
+
+	maintenance := &piraeusv1.LinstorNodeMaintenance{}
+
+	err := r.client.Get(ctx, request.NamespacedName, maintenance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// Request object not found, could have been deleted after reconcile request.
+			// Return and don't requeue
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, err
+	}
+
+	specErr := r.reconcileSpec(ctx, maintenance)
+
+	if maintenance.GetDeletionTimestamp() != nil && specErr == nil {
+		// Finalizer was removed, nothing left to update.
+		return reconcile.Result{}, nil
+	}
+
+	statusErr := r.reconcileStatus(ctx, maintenance, specErr)
+	if statusErr != nil {
+		log.Warnf("failed to update status. original error: %v", specErr)
+		return reconcile.Result{}, statusErr
+	}
+
+	result, err := reconcileutil.ToReconcileResult(specErr)
+
+	log.WithFields(logrus.Fields{
+		"result": result,
+		"err":    err,
+	}).Info("node maintenance Reconcile: reconcile loop end")
+
+	return reconcileutil.CombineReconcileResults(result, reconcile.Result{RequeueAfter: 1 * time.Minute}), err
+}
+
+func (r *ReconcileLinstorNodeMaintenance) reconcileSpec(ctx context.Context, maintenance *piraeusv1.LinstorNodeMaintenance) error {
+	log := log.WithFields(logrus.Fields{
+		"Name":      maintenance.Name,
+		"Namespace": maintenance.Namespace,
+		"Node":      maintenance.Spec.NodeName,
+		"Op":        "reconcileSpec",
+	})
+
+	log.Debug("fill default values")
+
+	err := r.reconcileResource(ctx, maintenance)
+	if err != nil {
+		return fmt.Errorf("failed to update spec using default values: %w", err)
+	}
+
+	linstorClient, err := lc.NewHighLevelLinstorClientFromConfig(
+		maintenance.Spec.ControllerEndpoint,
+		&maintenance.Spec.LinstorClientConfig,
+		lc.NamedSecret(ctx, r.client, maintenance.Spec.LinstorHttpsClientSecret),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create LINSTOR client: %w", err)
+	}
+
+	if maintenance.GetDeletionTimestamp() != nil {
+		log.Debug("restore node")
+
+		maintenance.Status.Phase = piraeusv1.NodeMaintenanceRestoring
+
+		err := r.restoreNode(ctx, linstorClient, maintenance)
+		if err != nil {
+			return err
+		}
+
+		mdutil.DeleteFinalizer(maintenance, linstorNodeMaintenanceFinalizer)
+
+		return r.client.Update(ctx, maintenance)
+	}
+
+	if !mdutil.HasFinalizer(maintenance, linstorNodeMaintenanceFinalizer) {
+		log.Debug("add finalizer")
+
+		mdutil.AddFinalizer(maintenance, linstorNodeMaintenanceFinalizer)
+
+		err := r.client.Update(ctx, maintenance)
+		if err != nil {
+			return fmt.Errorf("failed to add finalizer to resource: %w", err)
+		}
+	}
+
+	log.Debug("cordon node")
+
+	maintenance.Status.Phase = piraeusv1.NodeMaintenanceCordoning
+
+	err = r.cordonNode(ctx, linstorClient, maintenance)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("evacuate LINSTOR resources")
+
+	maintenance.Status.Phase = piraeusv1.NodeMaintenanceEvacuating
+
+	progress, err := linstorClient.Evacuate(ctx, maintenance.Spec.NodeName, lc.AllReplicas)
+	if err != nil {
+		return fmt.Errorf("failed to evacuate node: %w", err)
+	}
+
+	maintenance.Status.Resources = resourceStatuses(progress)
+
+	if !progress.Done() {
+		return &reconcileutil.TemporaryError{
+			Source:       fmt.Errorf("waiting for %d resources to be evacuated", len(progress.Remaining)),
+			RequeueAfter: retrySeconds * time.Second,
+		}
+	}
+
+	log.Debug("drain pods")
+
+	maintenance.Status.Phase = piraeusv1.NodeMaintenanceDraining
+
+	remaining, err := r.drainNode(ctx, maintenance.Spec.NodeName)
+	if err != nil {
+		return err
+	}
+
+	if remaining != 0 {
+		return &reconcileutil.TemporaryError{
+			Source:       fmt.Errorf("waiting for %d pods to be evicted", remaining),
+			RequeueAfter: retrySeconds * time.Second,
+		}
+	}
+
+	maintenance.Status.Phase = piraeusv1.NodeMaintenanceReady
+
+	log.Debug("node ready for maintenance")
+
+	return nil
+}
+
+func (r *ReconcileLinstorNodeMaintenance) reconcileResource(ctx context.Context, maintenance *piraeusv1.LinstorNodeMaintenance) error {
+	logger := log.WithFields(logrus.Fields{
+		"Name":      maintenance.Name,
+		"Namespace": maintenance.Namespace,
+		"Op":        "reconcileResource",
+	})
+	logger.Debug("performing upgrades and fill defaults in resource")
+
+	changed := false
+
+	logger.Debug("performing upgrade/fill: #1 -> Set default endpoint URL for Client")
+
+	if maintenance.Spec.ControllerEndpoint == "" {
+		controllers := &piraeusv1.LinstorControllerList{}
+
+		err := r.client.List(ctx, controllers, client.InNamespace(maintenance.Namespace))
+		if err != nil {
+			return err
+		}
+
+		if len(controllers.Items) != 1 {
+			return fmt.Errorf("`controllerEndpoint` not set, and found %d LinstorController resources in namespace '%s', expected 1", len(controllers.Items), maintenance.Namespace)
+		}
+
+		controllerResource := &controllers.Items[0]
+		serviceName := types.NamespacedName{Name: controllerResource.Name, Namespace: controllerResource.Namespace}
+		useHTTPS := controllerResource.Spec.LinstorHttpsClientSecret != ""
+
+		maintenance.Spec.ControllerEndpoint = lc.DefaultControllerServiceEndpoint(serviceName, useHTTPS)
+		maintenance.Spec.LinstorClientConfig = controllerResource.Spec.LinstorClientConfig
+		changed = true
+
+		logger.Infof("set controller endpoint URL to '%s'", maintenance.Spec.ControllerEndpoint)
+	}
+
+	logger.Debugf("finished upgrade/fill: #1 -> Set default endpoint URL for Client: changed=%t", changed)
+
+	logger.Debug("finished all upgrades/fills")
+
+	if changed {
+		logger.Info("save updated spec")
+		return r.client.Update(ctx, maintenance)
+	}
+
+	return nil
+}
+
+// cordonNode marks the node as unschedulable for new pods and new LINSTOR resources. Every change is recorded as
+// annotation on the kubernetes node, so only changes made by the operator are reverted once the maintenance is done.
+func (r *ReconcileLinstorNodeMaintenance) cordonNode(ctx context.Context, linstorClient *lc.HighLevelClient, maintenance *piraeusv1.LinstorNodeMaintenance) error {
+	node := &corev1.Node{}
+
+	err := r.client.Get(ctx, types.NamespacedName{Name: maintenance.Spec.NodeName}, node)
+	if err != nil {
+		return fmt.Errorf("failed to fetch node '%s': %w", maintenance.Spec.NodeName, err)
+	}
+
+	owner := maintenanceRef(maintenance)
+
+	linstorNode, err := linstorClient.Nodes.Get(ctx, maintenance.Spec.NodeName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch LINSTOR node '%s': %w", maintenance.Spec.NodeName, err)
+	}
+
+	disableAutoplace := linstorNode.Props[kubeSpec.LinstorAutoplaceTargetProperty] != "false"
+	cordon := !node.Spec.Unschedulable
+
+	if disableAutoplace || cordon {
+		if node.Annotations == nil {
+			node.Annotations = make(map[string]string)
+		}
+
+		if disableAutoplace {
+			node.Annotations[kubeSpec.AutoplaceDisabledByMaintenanceAnnotation] = owner
+		}
+
+		if cordon {
+			node.Spec.Unschedulable = true
+			node.Annotations[kubeSpec.CordonedByMaintenanceAnnotation] = owner
+		}
+
+		log.WithFields(logrus.Fields{
+			"node":             node.Name,
+			"cordon":           cordon,
+			"disableAutoplace": disableAutoplace,
+		}).Info("cordon node for maintenance")
+
+		err := r.client.Update(ctx, node)
+		if err != nil {
+			return fmt.Errorf("failed to cordon node '%s': %w", node.Name, err)
+		}
+	}
+
+	if disableAutoplace {
+		err := linstorClient.Nodes.Modify(ctx, linstorNode.Name, lapi.NodeModify{
+			GenericPropsModify: lapi.GenericPropsModify{
+				OverrideProps: map[string]string{kubeSpec.LinstorAutoplaceTargetProperty: "false"},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to exclude LINSTOR node '%s' from autoplacement: %w", linstorNode.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// restoreNode reverts the changes made by cordonNode.
+func (r *ReconcileLinstorNodeMaintenance) restoreNode(ctx context.Context, linstorClient *lc.HighLevelClient, maintenance *piraeusv1.LinstorNodeMaintenance) error {
+	node := &corev1.Node{}
+
+	err := r.client.Get(ctx, types.NamespacedName{Name: maintenance.Spec.NodeName}, node)
+	if errors.IsNotFound(err) {
+		log.WithField("node", maintenance.Spec.NodeName).Info("node already removed, nothing to restore")
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to fetch node '%s': %w", maintenance.Spec.NodeName, err)
+	}
+
+	owner := maintenanceRef(maintenance)
+
+	if node.Annotations[kubeSpec.AutoplaceDisabledByMaintenanceAnnotation] == owner {
+		err := linstorClient.Nodes.Modify(ctx, maintenance.Spec.NodeName, lapi.NodeModify{
+			GenericPropsModify: lapi.GenericPropsModify{
+				DeleteProps: []string{kubeSpec.LinstorAutoplaceTargetProperty},
+			},
+		})
+		if err != nil && err != lapi.NotFoundError {
+			return fmt.Errorf("failed to include LINSTOR node '%s' in autoplacement: %w", maintenance.Spec.NodeName, err)
+		}
+
+		delete(node.Annotations, kubeSpec.AutoplaceDisabledByMaintenanceAnnotation)
+	}
+
+	if node.Annotations[kubeSpec.CordonedByMaintenanceAnnotation] == owner {
+		node.Spec.Unschedulable = false
+
+		delete(node.Annotations, kubeSpec.CordonedByMaintenanceAnnotation)
+	}
+
+	log.WithField("node", node.Name).Info("restore node after maintenance")
+
+	err = r.client.Update(ctx, node)
+	if err != nil {
+		return fmt.Errorf("failed to restore node '%s': %w", node.Name, err)
+	}
+
+	return nil
+}
+
+// drainNode evicts all pods from the node, except those managed by a DaemonSet. Returns the number of pods still
+// running on the node.
+func (r *ReconcileLinstorNodeMaintenance) drainNode(ctx context.Context, nodeName string) (int, error) {
+	pods, err := r.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + nodeName})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pods on node '%s': %w", nodeName, err)
+	}
+
+	toEvict := podsToEvict(pods.Items)
+
+	remaining := 0
+
+	for i := range toEvict {
+		pod := &toEvict[i]
+
+		remaining++
+
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		log.WithFields(logrus.Fields{
+			"node":      nodeName,
+			"pod":       pod.Name,
+			"namespace": pod.Namespace,
+		}).Info("evicting pod")
+
+		err := r.clientset.PolicyV1beta1().Evictions(pod.Namespace).Evict(ctx, &policyv1beta1.Eviction{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		})
+		if errors.IsNotFound(err) {
+			remaining--
+			continue
+		}
+
+		// TooManyRequests is returned if the eviction would violate a PodDisruptionBudget, we retry later.
+		if err != nil && !errors.IsTooManyRequests(err) {
+			return 0, fmt.Errorf("failed to evict pod '%s/%s': %w", pod.Namespace, pod.Name, err)
+		}
+	}
+
+	return remaining, nil
+}
+
+func (r *ReconcileLinstorNodeMaintenance) reconcileStatus(ctx context.Context, maintenance *piraeusv1.LinstorNodeMaintenance, err error) error {
+	maintenance.Status.Errors = reconcileutil.ErrorStrings(err)
+
+	// Status update should always happen, even if the actual update context is canceled
+	updateCtx, updateCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer updateCancel()
+
+	return r.client.Status().Update(updateCtx, maintenance)
+}
+
+// podsToEvict returns the pods that need to be evicted to drain a node. Pods managed by a DaemonSet, static pods and
+// finished pods are skipped.
+func podsToEvict(pods []corev1.Pod) []corev1.Pod {
+	var result []corev1.Pod
+
+	for i := range pods {
+		pod := &pods[i]
+
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+
+		if _, ok := pod.Annotations[mirrorPodAnnotation]; ok {
+			continue
+		}
+
+		controllerRef := metav1.GetControllerOf(pod)
+		if controllerRef != nil && controllerRef.Kind == "DaemonSet" {
+			continue
+		}
+
+		result = append(result, *pod)
+	}
+
+	return result
+}
+
+// resourceStatuses converts the evacuation progress into the per-resource status.
+func resourceStatuses(progress *lc.EvacuationProgress) []*piraeusv1.NodeMaintenanceResourceStatus {
+	syncing := make(map[string]struct{}, len(progress.Syncing))
+	for _, name := range progress.Syncing {
+		syncing[name] = struct{}{}
+	}
+
+	result := make([]*piraeusv1.NodeMaintenanceResourceStatus, 0, len(progress.Remaining))
+
+	for _, name := range progress.Remaining {
+		state := piraeusv1.NodeMaintenanceResourceRemoving
+		if _, ok := syncing[name]; ok {
+			state = piraeusv1.NodeMaintenanceResourceSyncing
+		}
+
+		result = append(result, &piraeusv1.NodeMaintenanceResourceStatus{Name: name, State: state})
+	}
+
+	return result
+}
+
+// maintenanceRef returns the value used to mark changes on the node made for this maintenance.
+func maintenanceRef(maintenance *piraeusv1.LinstorNodeMaintenance) string {
+	return maintenance.Namespace + "/" + maintenance.Name
+}
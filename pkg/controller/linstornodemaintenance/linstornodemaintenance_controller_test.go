@@ -0,0 +1,85 @@
+package linstornodemaintenance
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+func TestPodsToEvict(t *testing.T) {
+	yes := true
+
+	pod := func(name string, phase corev1.PodPhase, ownerKind string, annotations map[string]string) corev1.Pod {
+		p := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+
+		if ownerKind != "" {
+			p.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: "owner", Controller: &yes}}
+		}
+
+		return p
+	}
+
+	pods := []corev1.Pod{
+		pod("app", corev1.PodRunning, "ReplicaSet", nil),
+		pod("bare", corev1.PodPending, "", nil),
+		pod("satellite", corev1.PodRunning, "DaemonSet", nil),
+		pod("static", corev1.PodRunning, "", map[string]string{mirrorPodAnnotation: "abc"}),
+		pod("job", corev1.PodSucceeded, "Job", nil),
+		pod("crashed", corev1.PodFailed, "Job", nil),
+	}
+
+	actual := podsToEvict(pods)
+
+	var names []string
+	for i := range actual {
+		names = append(names, actual[i].Name)
+	}
+
+	expected := []string{"app", "bare"}
+	if !reflect.DeepEqual(expected, names) {
+		t.Errorf("expected: %v, actual: %v", expected, names)
+	}
+}
+
+func TestResourceStatuses(t *testing.T) {
+	testcases := []struct {
+		name     string
+		progress *lc.EvacuationProgress
+		expected []*piraeusv1.NodeMaintenanceResourceStatus
+	}{
+		{
+			name:     "done",
+			progress: &lc.EvacuationProgress{},
+			expected: []*piraeusv1.NodeMaintenanceResourceStatus{},
+		},
+		{
+			name: "in-progress",
+			progress: &lc.EvacuationProgress{
+				Remaining: []string{"res1", "res2"},
+				Syncing:   []string{"res2"},
+			},
+			expected: []*piraeusv1.NodeMaintenanceResourceStatus{
+				{Name: "res1", State: piraeusv1.NodeMaintenanceResourceRemoving},
+				{Name: "res2", State: piraeusv1.NodeMaintenanceResourceSyncing},
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := resourceStatuses(tcase.progress)
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %+v, actual: %+v", tcase.expected, actual)
+			}
+		})
+	}
+}
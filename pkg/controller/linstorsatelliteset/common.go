@@ -2,6 +2,7 @@ package linstorsatelliteset
 
 import (
 	"os"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -17,6 +18,31 @@ const (
 	// Default value for automaticStorageType. If set, no automatic setup of storage devices happens.
 	automaticStorageTypeNone = "None"
 
+	// Reported as migration reason for storage pools that are no longer part of the spec.
+	storagePoolRemovedReason = "removed from spec"
+
+	// Default time to wait for a removed kubernetes node to come back, before its satellite is removed.
+	defaultDanglingSatelliteGracePeriod = 10 * time.Minute
+
+	// Default number of degraded resources listed per satellite in the status.
+	defaultResourceHealthDetailLimit = 10
+
+	// Default number of satellites that may be unavailable during a managed update.
+	defaultMaxUnavailableSatellites = 1
+
+	// Name of the LINSTOR satellite container.
+	satelliteContainerName = "linstor-satellite"
+
+	// Name of the drbd-reactor container. The name is kept from when drbd-reactor only exported metrics, so existing
+	// pods are not replaced.
+	monitoringContainerName = "drbd-prometheus-exporter"
+
+	// Name of the init container loading the DRBD kernel module.
+	kernelModuleInjectorContainerName = "kernel-module-injector"
+
+	// Name of the init container reporting the loaded DRBD version as termination message.
+	drbdVersionContainerName = "drbd-version"
+
 	// requeue reconciliation after connectionRetrySeconds
 	connectionRetrySeconds = 10
 )
@@ -0,0 +1,259 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+)
+
+// drbd-reactor runs next to the satellite if monitoring is enabled or any other plugin is configured. Every plugin
+// type is written to its own snippet in the drbd-reactor configuration directory.
+
+const drbdReactorConfigDir = "/etc/drbd-reactor.d/"
+
+// drbdReactorReloadScript starts drbd-reactor and reloads it once the mounted configuration changes.
+const drbdReactorReloadScript = `
+drbd-reactor &
+pid=$!
+trap 'kill -TERM $pid' TERM INT
+config() { cat ` + drbdReactorConfigDir + `*.toml 2>/dev/null | cksum; }
+current="$(config)"
+while kill -0 $pid 2>/dev/null; do
+  sleep 10 & wait $!
+  next="$(config)"
+  if [ "$next" != "$current" ]; then
+    current="$next"
+    kill -HUP $pid
+  fi
+done
+wait $pid
+`
+
+type drbdReactorPromoterResource struct {
+	Start               []string `toml:"start"`
+	Runner              string   `toml:"runner,omitempty"`
+	OnDrbdDemoteFailure string   `toml:"on-drbd-demote-failure,omitempty"`
+	StopServicesOnExit  bool     `toml:"stop-services-on-exit,omitempty"`
+}
+
+type drbdReactorPromoter struct {
+	Resources map[string]drbdReactorPromoterResource `toml:"resources"`
+}
+
+type drbdReactorUMHRule struct {
+	Name         string            `toml:"name"`
+	Command      string            `toml:"command"`
+	ResourceName string            `toml:"resource-name,omitempty"`
+	EventType    string            `toml:"event-type,omitempty"`
+	Old          map[string]string `toml:"old,omitempty"`
+	New          map[string]string `toml:"new,omitempty"`
+}
+
+type drbdReactorUMH struct {
+	Resource   []drbdReactorUMHRule `toml:"resource,omitempty"`
+	Device     []drbdReactorUMHRule `toml:"device,omitempty"`
+	PeerDevice []drbdReactorUMHRule `toml:"peerdevice,omitempty"`
+	Connection []drbdReactorUMHRule `toml:"connection,omitempty"`
+}
+
+type drbdReactorAgentX struct {
+	Address      string `toml:"address"`
+	CacheMax     int32  `toml:"cache-max,omitempty,omitzero"`
+	AgentTimeout int32  `toml:"agent-timeout,omitempty,omitzero"`
+	PeerStates   bool   `toml:"peer-states"`
+}
+
+// drbdReactorPlugins returns true if plugins besides prometheus are configured.
+func drbdReactorPlugins(set *piraeusv1.LinstorSatelliteSet) bool {
+	reactor := set.Spec.DrbdReactor
+	if reactor == nil {
+		return false
+	}
+
+	return len(reactor.Promoter) != 0 || len(reactor.UMH) != 0 || len(reactor.AgentX) != 0 || reactor.ExtraConfig != ""
+}
+
+// drbdReactorPrivileged returns true if drbd-reactor needs to change DRBD resources or run commands on the host.
+func drbdReactorPrivileged(set *piraeusv1.LinstorSatelliteSet) bool {
+	reactor := set.Spec.DrbdReactor
+
+	return reactor != nil && (len(reactor.Promoter) != 0 || len(reactor.UMH) != 0 || reactor.ExtraConfig != "")
+}
+
+// drbdReactorImage returns the image of the drbd-reactor container. Returns an empty string if drbd-reactor is not
+// deployed.
+func drbdReactorImage(set *piraeusv1.LinstorSatelliteSet) string {
+	if set.Spec.MonitoringImage != "" {
+		return set.Spec.MonitoringImage
+	}
+
+	if drbdReactorPlugins(set) {
+		return set.Spec.DrbdReactor.Image
+	}
+
+	return ""
+}
+
+// validateDrbdReactor checks that the drbd-reactor plugins can be deployed.
+func validateDrbdReactor(set *piraeusv1.LinstorSatelliteSet) error {
+	if !drbdReactorPlugins(set) {
+		return nil
+	}
+
+	if drbdReactorImage(set) == "" {
+		return fmt.Errorf("drbd-reactor plugins configured, but neither monitoringImage nor drbdReactor.image is set")
+	}
+
+	for _, umh := range set.Spec.DrbdReactor.UMH {
+		for _, rule := range umh.Rules {
+			if rule.Command == "" {
+				return fmt.Errorf("UMH rule '%s' has no command", rule.Name)
+			}
+		}
+	}
+
+	var extra map[string]interface{}
+
+	_, err := toml.Decode(set.Spec.DrbdReactor.ExtraConfig, &extra)
+	if err != nil {
+		return fmt.Errorf("invalid drbd-reactor extraConfig: %w", err)
+	}
+
+	return nil
+}
+
+// drbdReactorPluginConfig returns the configuration snippets of all plugins besides prometheus, by file name.
+func drbdReactorPluginConfig(set *piraeusv1.LinstorSatelliteSet) (map[string]string, error) {
+	result := make(map[string]string)
+
+	reactor := set.Spec.DrbdReactor
+	if reactor == nil {
+		return result, nil
+	}
+
+	if len(reactor.Promoter) != 0 {
+		promoters := make([]drbdReactorPromoter, 0, len(reactor.Promoter))
+
+		for _, promoter := range reactor.Promoter {
+			resources := make(map[string]drbdReactorPromoterResource, len(promoter.Resources))
+
+			for name, res := range promoter.Resources {
+				resources[name] = drbdReactorPromoterResource{
+					Start:               res.Start,
+					Runner:              res.Runner,
+					OnDrbdDemoteFailure: res.OnDrbdDemoteFailure,
+					StopServicesOnExit:  res.StopServicesOnExit,
+				}
+			}
+
+			promoters = append(promoters, drbdReactorPromoter{Resources: resources})
+		}
+
+		err := encodeDrbdReactorSnippet(result, "promoter.toml", struct {
+			Promoter []drbdReactorPromoter `toml:"promoter"`
+		}{Promoter: promoters})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(reactor.UMH) != 0 {
+		umhs := make([]drbdReactorUMH, 0, len(reactor.UMH))
+
+		for _, umh := range reactor.UMH {
+			var converted drbdReactorUMH
+
+			for _, rule := range umh.Rules {
+				r := drbdReactorUMHRule{
+					Name:         rule.Name,
+					Command:      rule.Command,
+					ResourceName: rule.ResourceName,
+					EventType:    rule.EventType,
+					Old:          rule.Old,
+					New:          rule.New,
+				}
+
+				switch rule.Type {
+				case "resource":
+					converted.Resource = append(converted.Resource, r)
+				case "device":
+					converted.Device = append(converted.Device, r)
+				case "peerdevice":
+					converted.PeerDevice = append(converted.PeerDevice, r)
+				case "connection":
+					converted.Connection = append(converted.Connection, r)
+				default:
+					return nil, fmt.Errorf("unknown UMH rule type '%s'", rule.Type)
+				}
+			}
+
+			umhs = append(umhs, converted)
+		}
+
+		err := encodeDrbdReactorSnippet(result, "umh.toml", struct {
+			UMH []drbdReactorUMH `toml:"umh"`
+		}{UMH: umhs})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(reactor.AgentX) != 0 {
+		agents := make([]drbdReactorAgentX, 0, len(reactor.AgentX))
+
+		for _, agent := range reactor.AgentX {
+			agents = append(agents, drbdReactorAgentX{
+				Address:      agent.Address,
+				CacheMax:     agent.CacheMax,
+				AgentTimeout: agent.AgentTimeout,
+				PeerStates:   agent.PeerStates,
+			})
+		}
+
+		err := encodeDrbdReactorSnippet(result, "agentx.toml", struct {
+			AgentX []drbdReactorAgentX `toml:"agentx"`
+		}{AgentX: agents})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if reactor.ExtraConfig != "" {
+		result["extra.toml"] = reactor.ExtraConfig
+	}
+
+	return result, nil
+}
+
+func encodeDrbdReactorSnippet(snippets map[string]string, name string, config interface{}) error {
+	builder := strings.Builder{}
+
+	err := toml.NewEncoder(&builder).Encode(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode drbd-reactor config '%s': %w", name, err)
+	}
+
+	snippets[name] = builder.String()
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package linstorsatelliteset
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+)
+
+func TestDrbdReactorPluginConfig(t *testing.T) {
+	set := &piraeusv1.LinstorSatelliteSet{
+		Spec: piraeusv1.LinstorSatelliteSetSpec{
+			DrbdReactor: &piraeusv1.DrbdReactorSpec{
+				Image: "drbd-reactor",
+				Promoter: []*piraeusv1.DrbdReactorPromoter{
+					{Resources: map[string]*piraeusv1.DrbdReactorPromoterResource{
+						"nfs": {Start: []string{"mount /dev/drbd1000 /srv", "nfsd"}, Runner: "shell"},
+					}},
+				},
+				UMH: []*piraeusv1.DrbdReactorUMH{
+					{Rules: []*piraeusv1.DrbdReactorUMHRule{
+						{Type: "resource", Name: "demoted", Command: "logger demoted", EventType: "Change", Old: map[string]string{"role": "Primary"}, New: map[string]string{"role": "Secondary"}},
+						{Type: "connection", Name: "lost", Command: "logger lost"},
+					}},
+				},
+				AgentX:      []*piraeusv1.DrbdReactorAgentX{{Address: "localhost:705", CacheMax: 60}},
+				ExtraConfig: "[[debugger]]\n",
+			},
+		},
+	}
+
+	if !drbdReactorPlugins(set) || !drbdReactorPrivileged(set) {
+		t.Errorf("expected plugins requiring privileges")
+	}
+
+	if drbdReactorImage(set) != "drbd-reactor" {
+		t.Errorf("expected drbd-reactor image without monitoring, got '%s'", drbdReactorImage(set))
+	}
+
+	set.Spec.MonitoringImage = "monitoring"
+	if drbdReactorImage(set) != "monitoring" {
+		t.Errorf("expected monitoring image to take precedence, got '%s'", drbdReactorImage(set))
+	}
+
+	snippets, err := drbdReactorPluginConfig(set)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var promoter struct {
+		Promoter []struct {
+			Resources map[string]struct {
+				Start  []string `toml:"start"`
+				Runner string   `toml:"runner"`
+			} `toml:"resources"`
+		} `toml:"promoter"`
+	}
+
+	_, err = toml.Decode(snippets["promoter.toml"], &promoter)
+	if err != nil {
+		t.Fatalf("failed to decode promoter config: %v\n%s", err, snippets["promoter.toml"])
+	}
+
+	if len(promoter.Promoter) != 1 || promoter.Promoter[0].Resources["nfs"].Runner != "shell" || !reflect.DeepEqual(promoter.Promoter[0].Resources["nfs"].Start, []string{"mount /dev/drbd1000 /srv", "nfsd"}) {
+		t.Errorf("unexpected promoter config: %+v", promoter)
+	}
+
+	var umh struct {
+		UMH []struct {
+			Resource []struct {
+				Name      string            `toml:"name"`
+				EventType string            `toml:"event-type"`
+				Old       map[string]string `toml:"old"`
+				New       map[string]string `toml:"new"`
+			} `toml:"resource"`
+			Connection []struct {
+				Command string `toml:"command"`
+			} `toml:"connection"`
+		} `toml:"umh"`
+	}
+
+	_, err = toml.Decode(snippets["umh.toml"], &umh)
+	if err != nil {
+		t.Fatalf("failed to decode umh config: %v\n%s", err, snippets["umh.toml"])
+	}
+
+	if len(umh.UMH) != 1 || len(umh.UMH[0].Resource) != 1 || len(umh.UMH[0].Connection) != 1 {
+		t.Fatalf("unexpected umh config: %+v", umh)
+	}
+
+	resourceRule := umh.UMH[0].Resource[0]
+	if resourceRule.EventType != "Change" || resourceRule.Old["role"] != "Primary" || resourceRule.New["role"] != "Secondary" {
+		t.Errorf("unexpected resource rule: %+v", resourceRule)
+	}
+
+	var agentx map[string]interface{}
+
+	_, err = toml.Decode(snippets["agentx.toml"], &agentx)
+	if err != nil {
+		t.Fatalf("failed to decode agentx config: %v\n%s", err, snippets["agentx.toml"])
+	}
+
+	if snippets["extra.toml"] != "[[debugger]]\n" {
+		t.Errorf("expected extra config to be passed through, got '%s'", snippets["extra.toml"])
+	}
+}
+
+func TestValidateDrbdReactor(t *testing.T) {
+	testcases := []struct {
+		name      string
+		spec      piraeusv1.LinstorSatelliteSetSpec
+		expectErr bool
+	}{
+		{name: "none"},
+		{name: "monitoring-only", spec: piraeusv1.LinstorSatelliteSetSpec{MonitoringImage: "monitoring"}},
+		{
+			name:      "no-image",
+			spec:      piraeusv1.LinstorSatelliteSetSpec{DrbdReactor: &piraeusv1.DrbdReactorSpec{AgentX: []*piraeusv1.DrbdReactorAgentX{{Address: "localhost:705"}}}},
+			expectErr: true,
+		},
+		{
+			name:      "invalid-extra-config",
+			spec:      piraeusv1.LinstorSatelliteSetSpec{DrbdReactor: &piraeusv1.DrbdReactorSpec{Image: "drbd-reactor", ExtraConfig: "[[promoter"}},
+			expectErr: true,
+		},
+		{
+			name:      "umh-without-command",
+			spec:      piraeusv1.LinstorSatelliteSetSpec{DrbdReactor: &piraeusv1.DrbdReactorSpec{Image: "drbd-reactor", UMH: []*piraeusv1.DrbdReactorUMH{{Rules: []*piraeusv1.DrbdReactorUMHRule{{Type: "resource", Name: "r"}}}}}},
+			expectErr: true,
+		},
+		{
+			name: "valid",
+			spec: piraeusv1.LinstorSatelliteSetSpec{DrbdReactor: &piraeusv1.DrbdReactorSpec{Image: "drbd-reactor", ExtraConfig: "[[debugger]]\n"}},
+		},
+	}
+
+	for _, tcase := range testcases {
+		tcase := tcase
+
+		t.Run(tcase.name, func(t *testing.T) {
+			err := validateDrbdReactor(&piraeusv1.LinstorSatelliteSet{Spec: tcase.spec})
+			if tcase.expectErr && err == nil {
+				t.Errorf("expected error")
+			}
+
+			if !tcase.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -28,8 +28,10 @@ import (
 	"github.com/BurntSushi/toml"
 	linstor "github.com/LINBIT/golinstor"
 	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/LINBIT/golinstor/devicelayerkind"
 	"github.com/sirupsen/logrus"
 	apps "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,6 +40,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -55,7 +58,11 @@ import (
 )
 
 func newSatelliteReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileLinstorSatelliteSet{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+	return &ReconcileLinstorSatelliteSet{
+		client:   mgr.GetClient(),
+		scheme:   mgr.GetScheme(),
+		recorder: mgr.GetEventRecorderFor("linstorsatelliteset-controller"),
+	}
 }
 
 func addSatelliteReconciler(mgr manager.Manager, r reconcile.Reconciler) error {
@@ -80,6 +87,14 @@ func addSatelliteReconciler(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	err = c.Watch(&source.Kind{Type: &batchv1.Job{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &piraeusv1.LinstorSatelliteSet{},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -90,8 +105,9 @@ var _ reconcile.Reconciler = &ReconcileLinstorSatelliteSet{}
 type ReconcileLinstorSatelliteSet struct {
 	// This Client, initialized using mgr.Client() above, is a split Client
 	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
+	client   client.Client
+	scheme   *runtime.Scheme
+	recorder record.EventRecorder
 }
 
 // Reconcile reads that state of the cluster for a LinstorSatelliteSet object and makes changes based on
@@ -188,6 +204,13 @@ func (r *ReconcileLinstorSatelliteSet) reconcileSpec(ctx context.Context, satell
 		return []error{fmt.Errorf("failed to add finalizer to resource: %w", err)}
 	}
 
+	log.Debug("check node selector overlap")
+
+	err = r.checkNodeSelectorOverlap(ctx, satelliteSet)
+	if err != nil {
+		return []error{err}
+	}
+
 	log.Debug("reconcile satellite configmap")
 
 	// Create the satellite configuration
@@ -203,50 +226,111 @@ func (r *ReconcileLinstorSatelliteSet) reconcileSpec(ctx context.Context, satell
 
 	log.WithField("changed", satelliteCMChanged).Debug("reconcile satellite configmap: done")
 
+	log.Debug("reconcile satellite config secret")
+
+	passwords, err := satelliteSet.Spec.SslOptions.Passwords(lc.NamedSecret(ctx, r.client, satelliteSet.Namespace))
+	if err != nil {
+		return []error{err}
+	}
+
+	satelliteSecret, err := newSatelliteConfigSecret(satelliteSet, passwords)
+	if err != nil {
+		return []error{fmt.Errorf("failed to reconcile satellite config secret: %w", err)}
+	}
+
+	satelliteSecretChanged, err := reconcileutil.CreateOrUpdateWithOwner(ctx, r.client, r.scheme, satelliteSecret, satelliteSet, reconcileutil.OnPatchErrorReturn)
+	if err != nil {
+		return []error{fmt.Errorf("failed to reconcile satellite config secret: %w", err)}
+	}
+
+	log.WithField("changed", satelliteSecretChanged).Debug("reconcile satellite config secret: done")
+
 	drbdReactorCM, err := r.reconcileMonitoring(ctx, satelliteSet)
 	if err != nil {
 		return []error{fmt.Errorf("failed to reconcile monitoring resources: %w", err)}
 	}
 
-	log.Debug("reconcile satellite daemonset")
-
-	ds := newSatelliteDaemonSet(satelliteSet, satelliteCM, drbdReactorCM)
+	log.Debug("reconcile kernel module injection groups")
 
-	daemonsetChanged, err := reconcileutil.CreateOrUpdateWithOwner(ctx, r.client, r.scheme, ds, satelliteSet, reconcileutil.OnPatchErrorRecreate)
+	satelliteSet.Status.NodesWithoutInjectionImage, err = r.reconcileNodeGroups(ctx, satelliteSet)
 	if err != nil {
-		return []error{fmt.Errorf("failed to reconcile satellite daemonset: %w", err)}
+		return []error{fmt.Errorf("failed to reconcile kernel module injection groups: %w", err)}
 	}
 
-	log.WithField("changed", daemonsetChanged).Debug("reconcile satellite daemonset: done")
+	log.Debug("reconcile satellite daemonsets")
 
-	if satelliteCMChanged && !daemonsetChanged {
-		log.Debug("restart LINSTOR Satellites")
+	onPatchErr := reconcileutil.OnPatchErrorRecreate
+	if satelliteSet.Spec.UpdateStrategy.Type == piraeusv1.SatelliteUpdateManaged {
+		// Keep the old satellite pods running, they are replaced node by node as part of the rollout.
+		onPatchErr = reconcileutil.OnPatchErrorRecreateOrphan
+	}
+
+	daemonSets := newSatelliteDaemonSets(satelliteSet, satelliteCM, drbdReactorCM)
 
-		err := reconcileutil.RestartRollout(ctx, r.client, ds)
+	for _, ds := range daemonSets {
+		daemonsetChanged, err := reconcileutil.CreateOrUpdateWithOwner(ctx, r.client, r.scheme, ds, satelliteSet, onPatchErr)
 		if err != nil {
-			return []error{fmt.Errorf("failed to restart LINSTOR Controller after ConfigMap change: %w", err)}
+			return []error{fmt.Errorf("failed to reconcile satellite daemonset '%s': %w", ds.Name, err)}
+		}
+
+		log.WithFields(logrus.Fields{
+			"daemonset": ds.Name,
+			"changed":   daemonsetChanged,
+		}).Debug("reconcile satellite daemonset: done")
+
+		if (satelliteCMChanged || satelliteSecretChanged) && !daemonsetChanged {
+			log.WithField("daemonset", ds.Name).Debug("restart LINSTOR Satellites")
+
+			err := reconcileutil.RestartRollout(ctx, r.client, ds)
+			if err != nil {
+				return []error{fmt.Errorf("failed to restart LINSTOR Satellites after ConfigMap change: %w", err)}
+			}
 		}
 	}
 
-	return r.reconcileAllNodesOnController(ctx, satelliteSet)
+	err = r.removeStaleNodeGroupDaemonSets(ctx, satelliteSet, daemonSets)
+	if err != nil {
+		return []error{err}
+	}
+
+	errs := r.reconcileAllNodesOnController(ctx, satelliteSet)
+
+	log.Debug("reconcile satellite rollout")
+
+	err = r.reconcileSatelliteRollout(ctx, satelliteSet)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
 }
 
 func (r *ReconcileLinstorSatelliteSet) reconcileMonitoring(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet) (*corev1.ConfigMap, error) {
-	if satelliteSet.Spec.MonitoringImage == "" {
+	if drbdReactorImage(satelliteSet) == "" {
 		return nil, nil
 	}
 
 	log.Debug("reconcile drbd-reactor configmap")
 
-	drbdReactorCM := newMonitoringConfigMap(satelliteSet)
+	drbdReactorCM, err := newMonitoringConfigMap(satelliteSet)
+	if err != nil {
+		return nil, err
+	}
 
 	drbdReactorCMChanged, err := reconcileutil.CreateOrUpdateWithOwner(ctx, r.client, r.scheme, drbdReactorCM, satelliteSet, reconcileutil.OnPatchErrorReturn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reconcile drbd-reactor configmap")
 	}
 
+	// drbd-reactor reloads the configuration itself, no restart needed.
 	log.WithField("changed", drbdReactorCMChanged).Debug("reconcile drbd-reactor configmap: done")
 
+	if satelliteSet.Spec.MonitoringImage == "" {
+		log.Debug("monitoring disabled, only drbd-reactor plugins deployed")
+
+		return drbdReactorCM, nil
+	}
+
 	log.Debug("reconciling monitoring service definition")
 
 	monitoringService := newMonitoringService(satelliteSet)
@@ -265,6 +349,8 @@ func (r *ReconcileLinstorSatelliteSet) reconcileMonitoring(ctx context.Context,
 
 		serviceMonitor := monitoring.MonitorForService(monitoringService)
 
+		serviceMonitorWithProxy(serviceMonitor, satelliteSet, monitoringService)
+
 		serviceMonitorChanged, err := reconcileutil.CreateOrUpdateWithOwner(ctx, r.client, r.scheme, serviceMonitor, satelliteSet, reconcileutil.OnPatchErrorReturn)
 		if err != nil {
 			return nil, fmt.Errorf("failed to reconcile servicemonitor definition: %w", err)
@@ -316,6 +402,20 @@ func (r *ReconcileLinstorSatelliteSet) reconcileResource(ctx context.Context, sa
 		logger.Info("set storage pool 'ZFSPool' to empty list")
 	}
 
+	if satelliteSet.Spec.StoragePools.FilePools == nil {
+		satelliteSet.Spec.StoragePools.FilePools = make([]*shared.StoragePoolFile, 0)
+		changed = true
+
+		logger.Info("set storage pool 'FilePool' to empty list")
+	}
+
+	if satelliteSet.Spec.StoragePools.FileThinPools == nil {
+		satelliteSet.Spec.StoragePools.FileThinPools = make([]*shared.StoragePoolFileThin, 0)
+		changed = true
+
+		logger.Info("set storage pool 'FileThinPool' to empty list")
+	}
+
 	logger.Debugf("finished upgrade/fill: #0 -> replace nil with zero objects: changed=%t", changed)
 
 	logger.Debug("performing upgrade/fill: #1 -> Set default endpoint URL for Client")
@@ -360,6 +460,270 @@ func (r *ReconcileLinstorSatelliteSet) reconcileResource(ctx context.Context, sa
 
 	logger.Debugf("performing upgrade/full: #3 -> Set default VG name for LVMTHIN pools with device spec: changed=%t", changed)
 
+	logger.Debug("performing upgrade/full: #4 -> Validate vdev layout for ZFS pools with device spec")
+
+	for _, pool := range satelliteSet.Spec.StoragePools.ZFSPools {
+		if len(pool.DevicePaths) == 0 {
+			continue
+		}
+
+		_, err := pool.ZPoolCreateCommand()
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debugf("performing upgrade/full: #4 -> Validate vdev layout for ZFS pools with device spec: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #5 -> Set default storage pool migration policy")
+
+	if satelliteSet.Spec.StoragePoolMigrationPolicy == "" {
+		satelliteSet.Spec.StoragePoolMigrationPolicy = shared.StoragePoolMigrationManual
+		changed = true
+
+		logger.Infof("set default storage pool migration policy to '%s'", shared.StoragePoolMigrationManual)
+	}
+
+	logger.Debugf("finished upgrade/fill: #5 -> Set default storage pool migration policy: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #6 -> Set default storage pool removal policy")
+
+	if satelliteSet.Spec.StoragePoolRemovalPolicy == "" {
+		satelliteSet.Spec.StoragePoolRemovalPolicy = shared.StoragePoolRemovalManual
+		changed = true
+
+		logger.Infof("set default storage pool removal policy to '%s'", shared.StoragePoolRemovalManual)
+	}
+
+	logger.Debugf("finished upgrade/fill: #6 -> Set default storage pool removal policy: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #7 -> Set default policy for satellites without kubernetes node")
+
+	if satelliteSet.Spec.DanglingSatellitePolicy == "" {
+		satelliteSet.Spec.DanglingSatellitePolicy = shared.DanglingSatelliteEvictAndLost
+		changed = true
+
+		logger.Infof("set default dangling satellite policy to '%s'", shared.DanglingSatelliteEvictAndLost)
+	}
+
+	if satelliteSet.Spec.DanglingSatelliteGracePeriod == nil {
+		satelliteSet.Spec.DanglingSatelliteGracePeriod = &metav1.Duration{Duration: defaultDanglingSatelliteGracePeriod}
+		changed = true
+
+		logger.Infof("set default dangling satellite grace period to '%s'", defaultDanglingSatelliteGracePeriod)
+	}
+
+	logger.Debugf("finished upgrade/fill: #7 -> Set default policy for satellites without kubernetes node: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #8 -> Validate additional network interfaces")
+
+	err := shared.ValidateIPFamilies(satelliteSet.Spec.IPFamilies)
+	if err != nil {
+		return err
+	}
+
+	nicNames := sets.NewString(kubeSpec.LinstorDefaultNetInterface)
+
+	for i := 1; i < len(satelliteSet.Spec.IPFamilies); i++ {
+		nicNames.Insert(satelliteSet.Spec.IPFamilies[i].NetInterfaceName())
+	}
+
+	for _, nic := range satelliteSet.Spec.NetInterfaces {
+		err := nic.Validate()
+		if err != nil {
+			return err
+		}
+
+		if nicNames.Has(nic.Name) {
+			return fmt.Errorf("netInterfaces: interface '%s' is defined twice", nic.Name)
+		}
+
+		nicNames.Insert(nic.Name)
+	}
+
+	if satelliteSet.Spec.PrefNic != "" && !nicNames.Has(satelliteSet.Spec.PrefNic) {
+		return fmt.Errorf("prefNic: '%s' is not a configured network interface", satelliteSet.Spec.PrefNic)
+	}
+
+	logger.Debugf("finished upgrade/fill: #8 -> Validate additional network interfaces: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #9 -> Validate node label sync")
+
+	if satelliteSet.Spec.NodeLabelSync != nil {
+		err := satelliteSet.Spec.NodeLabelSync.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debugf("finished upgrade/fill: #9 -> Validate node label sync: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #10 -> Validate additional node properties")
+
+	err = shared.ValidateNodePropertyNames(satelliteSet.Spec.AdditionalProperties)
+	if err != nil {
+		return fmt.Errorf("additionalProperties: %w", err)
+	}
+
+	for _, nodeProps := range satelliteSet.Spec.NodeProperties {
+		err := nodeProps.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debugf("finished upgrade/fill: #10 -> Validate additional node properties: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #11 -> Set default update strategy")
+
+	if satelliteSet.Spec.UpdateStrategy == nil {
+		satelliteSet.Spec.UpdateStrategy = &piraeusv1.SatelliteUpdateStrategy{}
+		changed = true
+
+		logger.Info("set update strategy to empty default object")
+	}
+
+	if satelliteSet.Spec.UpdateStrategy.Type == "" {
+		satelliteSet.Spec.UpdateStrategy.Type = piraeusv1.SatelliteUpdateRollingUpdate
+		changed = true
+
+		logger.Infof("set default update strategy to '%s'", piraeusv1.SatelliteUpdateRollingUpdate)
+	}
+
+	if satelliteSet.Spec.UpdateStrategy.MaxUnavailable == 0 {
+		satelliteSet.Spec.UpdateStrategy.MaxUnavailable = defaultMaxUnavailableSatellites
+		changed = true
+
+		logger.Infof("set default max unavailable satellites to '%d'", defaultMaxUnavailableSatellites)
+	}
+
+	logger.Debugf("finished upgrade/fill: #11 -> Set default update strategy: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #12 -> Validate kernel module injection images")
+
+	groupNames := sets.NewString()
+
+	for _, image := range satelliteSet.Spec.KernelModuleInjectionImages {
+		err := image.Validate()
+		if err != nil {
+			return err
+		}
+
+		if groupNames.Has(image.Name) {
+			return fmt.Errorf("kernelModuleInjectionImages: '%s' is defined twice", image.Name)
+		}
+
+		groupNames.Insert(image.Name)
+	}
+
+	logger.Debugf("finished upgrade/fill: #12 -> Validate kernel module injection images: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #13 -> Set default preflight policy")
+
+	if satelliteSet.Spec.PreflightPolicy == "" {
+		satelliteSet.Spec.PreflightPolicy = shared.PreflightReport
+		changed = true
+
+		logger.Infof("set default preflight policy to '%s'", shared.PreflightReport)
+	}
+
+	logger.Debugf("finished upgrade/fill: #13 -> Set default preflight policy: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #14 -> Validate automatic storage device selection")
+
+	if satelliteSet.Spec.AutomaticStorageDevices != nil {
+		err := satelliteSet.Spec.AutomaticStorageDevices.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	logger.Debugf("finished upgrade/fill: #14 -> Validate automatic storage device selection: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #15 -> Set default resource health detail limit")
+
+	if satelliteSet.Spec.ResourceHealthDetailLimit == nil {
+		limit := int32(defaultResourceHealthDetailLimit)
+		satelliteSet.Spec.ResourceHealthDetailLimit = &limit
+		changed = true
+
+		logger.Infof("set default resource health detail limit to '%d'", limit)
+	}
+
+	logger.Debugf("finished upgrade/fill: #15 -> Set default resource health detail limit: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #16 -> Validate TLS options")
+
+	err = satelliteSet.Spec.SslOptions.Validate()
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("finished upgrade/fill: #16 -> Validate TLS options: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #17 -> Validate drbd-reactor plugins")
+
+	err = validateDrbdReactor(satelliteSet)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("finished upgrade/fill: #17 -> Validate drbd-reactor plugins: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #18 -> Validate monitoring proxy")
+
+	err = validateMonitoringProxy(satelliteSet)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("finished upgrade/fill: #18 -> Validate monitoring proxy: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #19 -> Validate node overrides")
+
+	overrideNames := sets.NewString()
+
+	for _, override := range satelliteSet.Spec.NodeOverrides {
+		err := override.Validate()
+		if err != nil {
+			return err
+		}
+
+		if overrideNames.Has(override.Name) {
+			return fmt.Errorf("nodeOverrides: '%s' is defined twice", override.Name)
+		}
+
+		overrideNames.Insert(override.Name)
+	}
+
+	err = validateNodeGroupNames(satelliteSet)
+	if err != nil {
+		return err
+	}
+
+	logger.Debugf("finished upgrade/fill: #19 -> Validate node overrides: changed=%t", changed)
+
+	logger.Debug("performing upgrade/fill: #20 -> Validate replacement devices of storage pools")
+
+	if satelliteSet.Spec.StoragePools != nil {
+		for _, pool := range satelliteSet.Spec.StoragePools.AllPhysicalStorageCreators() {
+			rebuilder, ok := pool.(shared.StoragePoolRebuilder)
+			if !ok {
+				continue
+			}
+
+			devicePaths := sets.NewString(pool.GetDevicePaths()...)
+
+			for _, dev := range rebuilder.GetReplaceDevices() {
+				if !devicePaths.Has(dev) {
+					return fmt.Errorf("storage pool '%s': replacement device '%s' is not part of `devicePaths`", pool.GetName(), dev)
+				}
+			}
+		}
+	}
+
+	logger.Debugf("finished upgrade/fill: #20 -> Validate replacement devices of storage pools: changed=%t", changed)
+
 	logger.Debug("finished all upgrades/fills")
 
 	if changed {
@@ -448,9 +812,21 @@ func (r *ReconcileLinstorSatelliteSet) reconcileAllNodesOnController(ctx context
 		return nonNilErrs
 	}
 
+	logger.Debug("remove physical devices of nodes without satellite")
+
+	satelliteNodes := make(map[string]bool, len(pods))
+	for i := range pods {
+		satelliteNodes[pods[i].Spec.NodeName] = true
+	}
+
+	err = r.removeStalePhysicalDevices(ctx, satelliteSet, satelliteNodes)
+	if err != nil {
+		return []error{err}
+	}
+
 	logger.Debug("remove registered satellites without Kubernetes node")
 
-	err = r.removeDanglingSatellites(ctx, linstorClient, k8sNodes.Items)
+	err = r.removeDanglingSatellites(ctx, linstorClient, satelliteSet, k8sNodes.Items)
 	if err != nil {
 		return []error{err}
 	}
@@ -486,25 +862,56 @@ func (r *ReconcileLinstorSatelliteSet) reconcilePod(ctx context.Context, linstor
 		return err
 	}
 
+	podLog.Debug("reattach replicas of replaced storage pools")
+
+	err = r.reattachReplacedReplicas(ctx, linstorClient, satelliteSet, pod.Spec.NodeName)
+	if err != nil {
+		return err
+	}
+
 	podLog.Debug("reconcile node registration: success")
 
 	return nil
 }
 
 func (r *ReconcileLinstorSatelliteSet) reconcileSingleNodeRegistration(ctx context.Context, linstorClient *lc.HighLevelClient, satelliteSet *piraeusv1.LinstorSatelliteSet, pod *corev1.Pod, k8sNode *corev1.Node) error {
+	err := r.checkSatelliteOwner(ctx, linstorClient, satelliteSet, pod.Spec.NodeName)
+	if err != nil {
+		return err
+	}
+
+	netInterfaces, err := lc.DefaultNetInterfaces(satelliteAddresses(pod, k8sNode), satelliteSet.Spec.IPFamilies, satelliteSet.Spec.SslConfig)
+	if err != nil {
+		return fmt.Errorf("failed to determine address of node '%s': %w", pod.Spec.NodeName, err)
+	}
+
+	for _, nic := range satelliteSet.Spec.NetInterfaces {
+		addr, err := nic.Address(k8sNode, pod)
+		if err != nil {
+			return fmt.Errorf("failed to determine network interface address: %w", err)
+		}
+
+		netInterfaces = append(netInterfaces, lapi.NetInterface{Name: nic.Name, Address: addr})
+	}
+
+	props := nodeLabelsToProps(k8sNode.Labels, satelliteSet.Spec.NodeLabelSync)
+	props[kubeSpec.LinstorSatelliteSetProperty] = satelliteSetOwner(satelliteSet)
+
+	if satelliteSet.Spec.PrefNic != "" {
+		props[kubeSpec.LinstorPrefNicProperty] = satelliteSet.Spec.PrefNic
+	}
+
+	for k, v := range nodePropertiesFromSpec(satelliteSet, k8sNode) {
+		props[k] = v
+	}
+
+	props[kubeSpec.LinstorManagedPropertiesProperty] = managedNodePropsValue(props)
+
 	lNode, err := linstorClient.GetNodeOrCreate(ctx, lapi.Node{
-		Name:  pod.Spec.NodeName,
-		Type:  lc.Satellite,
-		Props: nodeLabelsToProps(k8sNode.Labels),
-		NetInterfaces: []lapi.NetInterface{
-			{
-				Name:                    "default",
-				Address:                 pod.Status.HostIP,
-				IsActive:                true,
-				SatellitePort:           satelliteSet.Spec.SslConfig.Port(),
-				SatelliteEncryptionType: satelliteSet.Spec.SslConfig.Type(),
-			},
-		},
+		Name:          pod.Spec.NodeName,
+		Type:          lc.Satellite,
+		Props:         props,
+		NetInterfaces: netInterfaces,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to reconcile satellite: %w", err)
@@ -520,6 +927,57 @@ func (r *ReconcileLinstorSatelliteSet) reconcileSingleNodeRegistration(ctx conte
 	return nil
 }
 
+// nodePropertiesFromSpec returns the additional properties the spec sets on the satellite running on the node.
+func nodePropertiesFromSpec(satelliteSet *piraeusv1.LinstorSatelliteSet, k8sNode *corev1.Node) map[string]string {
+	result := make(map[string]string)
+
+	for k, v := range satelliteSet.Spec.AdditionalProperties {
+		result[k] = v
+	}
+
+	for _, nodeProps := range satelliteSet.Spec.NodeProperties {
+		if !nodeProps.Matches(k8sNode) {
+			continue
+		}
+
+		for k, v := range nodeProps.Properties {
+			result[k] = v
+		}
+	}
+
+	return result
+}
+
+// managedNodePropsValue returns the value of the marker property recording the node properties set by the operator,
+// so they can be removed again once they are no longer part of the spec or the label is removed.
+func managedNodePropsValue(props map[string]string) string {
+	managed := make(map[string]string, len(props))
+
+	for k, v := range props {
+		if k != kubeSpec.LinstorRegistrationProperty && k != kubeSpec.LinstorSatelliteSetProperty && k != kubeSpec.LinstorManagedPropertiesProperty {
+			managed[k] = v
+		}
+	}
+
+	return shared.ManagedPropertiesValue(managed)
+}
+
+// satelliteAddresses returns the addresses of the node running the satellite pod, starting with the primary address.
+// Satellites use the host network, so the pod addresses are also node addresses.
+func satelliteAddresses(pod *corev1.Pod, k8sNode *corev1.Node) []string {
+	result := []string{pod.Status.HostIP}
+
+	result = append(result, lc.PodAddresses(pod)...)
+
+	for _, addr := range k8sNode.Status.Addresses {
+		if addr.Type == corev1.NodeInternalIP {
+			result = append(result, addr.Address)
+		}
+	}
+
+	return result
+}
+
 func (r *ReconcileLinstorSatelliteSet) reconcileAutomaticDeviceSetup(ctx context.Context, linstorClient *lc.HighLevelClient, satelliteSet *piraeusv1.LinstorSatelliteSet, pod *corev1.Pod) error {
 	logger := log.WithFields(logrus.Fields{
 		"Name":      satelliteSet.Name,
@@ -564,6 +1022,23 @@ func (r *ReconcileLinstorSatelliteSet) reconcileAutomaticDeviceSetup(ctx context
 
 	logger.WithField("emptyDevices", emptyDevices).Debug("got available devices")
 
+	logger.Debug("update physical device inventory")
+
+	err = r.reconcilePhysicalDevices(ctx, satelliteSet, pod.Spec.NodeName, storageList)
+	if err != nil {
+		return err
+	}
+
+	existingPools, err := linstorClient.Nodes.GetStoragePools(ctx, pod.Spec.NodeName)
+	if err != nil && err != lapi.NotFoundError {
+		return err
+	}
+
+	existingPoolNames := sets.NewString()
+	for i := range existingPools {
+		existingPoolNames.Insert(existingPools[i].StoragePoolName)
+	}
+
 	for _, pool := range satelliteSet.Spec.StoragePools.AllPhysicalStorageCreators() {
 		logger := logger.WithField("pool", pool)
 
@@ -574,13 +1049,81 @@ func (r *ReconcileLinstorSatelliteSet) reconcileAutomaticDeviceSetup(ctx context
 			continue
 		}
 
+		existing := operatorCreatedPool(existingPools, pool.GetName())
+
+		if rebuilder, ok := pool.(shared.StoragePoolRebuilder); ok && existing != nil {
+			replace := existing.Props[kubeSpec.LinstorReplacementPendingProperty] != ""
+
+			// Only rebuild the pool if requested: new devices for a failed pool may also be meant to extend it.
+			if !replace && emptyDevices.HasAny(rebuilder.GetReplaceDevices()...) {
+				nodeResources, err := linstorClient.GetAllResourcesOnNode(ctx, pod.Spec.NodeName)
+				if err != nil {
+					return fmt.Errorf("failed to fetch resources on node '%s': %w", pod.Spec.NodeName, err)
+				}
+
+				replace = storagePoolFailure(existing, pool.GetDevicePaths(), nodeResources) != nil
+			}
+
+			if replace {
+				logger.Info("new devices for failed storage pool, rebuilding the pool")
+
+				return r.replaceFailedStoragePool(ctx, linstorClient, satelliteSet, pod.Spec.NodeName, existing, rebuilder)
+			}
+		}
+
 		if !emptyDevices.HasAll(pool.GetDevicePaths()...) {
-			return fmt.Errorf("failed to prepare storage devices for pool '%s' on node '%s': not all devices present and empty", pool.GetName(), pod.Spec.NodeName)
+			extender, ok := pool.(shared.VolumeGroupExtender)
+			if !ok || existing == nil {
+				return fmt.Errorf("failed to prepare storage devices for pool '%s' on node '%s': not all devices present and empty", pool.GetName(), pod.Spec.NodeName)
+			}
+
+			// Some devices are already part of the pool: the remaining empty devices were added to the spec later.
+			var newDevices []string
+
+			for _, devicePath := range pool.GetDevicePaths() {
+				if emptyDevices.Has(devicePath) {
+					newDevices = append(newDevices, devicePath)
+				}
+			}
+
+			logger.WithField("devices", newDevices).Info("extending volume group of existing storage pool")
+
+			cmd, err := extender.VolumeGroupExtendCommand(newDevices)
+			if err != nil {
+				return err
+			}
+
+			// The new capacity is reported in the storage pool status once LINSTOR picked up the change.
+			err = r.runNodeAction(ctx, satelliteSet, pod.Spec.NodeName, "vg-extend", cmd)
+			if err != nil {
+				return err
+			}
+
+			r.recorder.Eventf(satelliteSet, corev1.EventTypeNormal, "StoragePoolExtended", "Added devices %s to storage pool '%s' on node '%s'", strings.Join(newDevices, ", "), pool.GetName(), pod.Spec.NodeName)
+
+			emptyDevices.Delete(newDevices...)
+
+			continue
 		}
 
-		err := linstorClient.Nodes.CreateDevicePool(ctx, pod.Spec.NodeName, pool.ToPhysicalStorageCreate())
-		if err != nil {
-			return err
+		if zfsPool, ok := pool.(*shared.StoragePoolZFS); ok && zfsPool.NeedsVDevSetup() {
+			logger.Debug("LINSTOR can't create the requested vdev layout, creating zpool on node")
+
+			cmd, err := zfsPool.ZPoolCreateCommand()
+			if err != nil {
+				return err
+			}
+
+			// The storage pool itself is created as part of the regular storage pool reconciliation.
+			err = r.runNodeAction(ctx, satelliteSet, pod.Spec.NodeName, "zpool-create", cmd)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := linstorClient.Nodes.CreateDevicePool(ctx, pod.Spec.NodeName, pool.ToPhysicalStorageCreate())
+			if err != nil {
+				return err
+			}
 		}
 
 		emptyDevices.Delete(pool.GetDevicePaths()...)
@@ -593,13 +1136,31 @@ func (r *ReconcileLinstorSatelliteSet) reconcileAutomaticDeviceSetup(ctx context
 		return nil
 	}
 
-	for emptyDevice := range emptyDevices {
-		// Note: not found returns -1, so in this case name == path, which is exactly what we want
-		idx := strings.LastIndex(emptyDevice, "/")
-		name := "autopool-" + emptyDevice[idx+1:]
+	var remaining []shared.DeviceInfo
+
+	for _, device := range nodeDevices(storageList, pod.Spec.NodeName) {
+		if emptyDevices.Has(device.Path) {
+			remaining = append(remaining, device)
+		}
+	}
+
+	autoPools := automaticStoragePools(satelliteSet.Spec.AutomaticStorageDevices, remaining)
+	if len(autoPools) == 0 {
+		return nil
+	}
+
+	for _, name := range sets.StringKeySet(autoPools).List() {
+		if existingPoolNames.Has(name) {
+			logger.WithFields(logrus.Fields{
+				"pool":    name,
+				"devices": autoPools[name],
+			}).Warn("storage pool already exists, not adding new devices")
+
+			continue
+		}
 
 		err := linstorClient.Nodes.CreateDevicePool(ctx, pod.Spec.NodeName, lapi.PhysicalStorageCreate{
-			DevicePaths: []string{emptyDevice},
+			DevicePaths: autoPools[name],
 			PoolName:    name,
 			WithStoragePool: lapi.PhysicalStorageStoragePoolCreate{
 				Name: name,
@@ -610,7 +1171,6 @@ func (r *ReconcileLinstorSatelliteSet) reconcileAutomaticDeviceSetup(ctx context
 			return err
 		}
 	}
-
 	return nil
 }
 
@@ -631,6 +1191,8 @@ func (r *ReconcileLinstorSatelliteSet) reconcileStoragePoolsOnNode(ctx context.C
 
 	poolsFromSpec := satelliteSet.Spec.StoragePools.All()
 
+	var evacuating []string
+
 	for i := range currentPools {
 		existingPool := &currentPools[i]
 
@@ -662,46 +1224,276 @@ func (r *ReconcileLinstorSatelliteSet) reconcileStoragePoolsOnNode(ctx context.C
 
 		log.WithField("spec", matchingSpec).Debug("search complete")
 
-		if matchingSpec == nil {
-			log.WithField("pool", existingPool.StoragePoolName).Debug("removing outdated storage pool")
+		if matchingSpec == nil {
+			log.WithField("pool", existingPool.StoragePoolName).Debug("removing outdated storage pool")
+
+			removal, err := r.removeStoragePool(ctx, linstorClient, satelliteSet, pod.Spec.NodeName, existingPool.StoragePoolName)
+			if err != nil {
+				return err
+			}
+
+			if removal != nil && removal.Phase == shared.StoragePoolMigrationEvacuating {
+				evacuating = append(evacuating, existingPool.StoragePoolName)
+			}
+
+			continue
+		}
+
+		fromSpec := matchingSpec.ToLinstorStoragePool()
+
+		reason := storagePoolMismatch(existingPool, &fromSpec)
+		if reason != "" {
+			log.WithField("reason", reason).Info("storage pool does not match the spec")
+
+			migration, err := r.migrateStoragePool(ctx, linstorClient, satelliteSet, pod.Spec.NodeName, &fromSpec, reason)
+			if err != nil {
+				return err
+			}
+
+			if migration != nil && migration.Phase == shared.StoragePoolMigrationEvacuating {
+				evacuating = append(evacuating, existingPool.StoragePoolName)
+			}
+
+			continue
+		}
+
+		err := reconcileStoragePoolProps(ctx, linstorClient, pod.Spec.NodeName, existingPool, &fromSpec)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pool := range poolsFromSpec {
+		log.WithField("spec pool", pool).Debug("creating missing storage pool")
+		err := linstorClient.Nodes.CreateStoragePool(ctx, pod.Spec.NodeName, pool.ToLinstorStoragePool())
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(evacuating) != 0 {
+		return &reconcileutil.TemporaryError{
+			Source:       fmt.Errorf("waiting for storage pools %v on node '%s' to be evacuated", evacuating, pod.Spec.NodeName),
+			RequeueAfter: connectionRetrySeconds * time.Second,
+		}
+	}
+
+	log.Debug("reconcile storage pools: finished")
+
+	return nil
+}
+
+// storagePoolMismatch returns a description of the differences between an existing pool and the spec, that can't be
+// changed in place. Returns an empty string if the pool matches the spec.
+func storagePoolMismatch(existingPool, fromSpec *lapi.StoragePool) string {
+	if fromSpec.ProviderKind != existingPool.ProviderKind {
+		return fmt.Sprintf("provider changed from '%s' to '%s'", existingPool.ProviderKind, fromSpec.ProviderKind)
+	}
+
+	userProps := sets.NewString(shared.ManagedPropertyKeys(fromSpec.Props)...)
+
+	var changed []string
+
+	// We check that properties required by the storage driver are present and match, as they can't be changed on
+	// an existing pool. Any properties that are in LINSTOR but not in the spec are ignored.
+	for k, v := range fromSpec.Props {
+		if userProps.Has(k) || k == kubeSpec.LinstorManagedPropertiesProperty {
+			continue
+		}
+
+		existing, ok := existingPool.Props[k]
+		if !ok || existing != v {
+			changed = append(changed, fmt.Sprintf("%s: '%s' -> '%s'", k, existing, v))
+		}
+	}
+
+	if len(changed) == 0 {
+		return ""
+	}
+
+	sort.Strings(changed)
+
+	return "properties changed: " + strings.Join(changed, ", ")
+}
+
+// migrateStoragePool replaces an existing pool that no longer matches the spec.
+//
+// Empty pools are deleted and recreated right away. If the pool still contains resources, they are evacuated first
+// if requested by the migration policy. Returns the current state of the migration, or nil if the pool was recreated.
+func (r *ReconcileLinstorSatelliteSet) migrateStoragePool(ctx context.Context, linstorClient *lc.HighLevelClient, satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName string, fromSpec *lapi.StoragePool, reason string) (*shared.StoragePoolMigrationStatus, error) {
+	logger := log.WithFields(logrus.Fields{
+		"Name":      satelliteSet.Name,
+		"Namespace": satelliteSet.Namespace,
+		"Node":      nodeName,
+		"Pool":      fromSpec.StoragePoolName,
+		"Op":        "migrateStoragePool",
+	})
+
+	resources, err := linstorClient.GetAllResourcesOnNode(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch resources on node '%s': %w", nodeName, err)
+	}
+
+	evacuate := satelliteSet.Spec.StoragePoolMigrationPolicy == shared.StoragePoolMigrationEvacuate
+	migration := storagePoolMigrationStatus(evacuate, fromSpec.StoragePoolName, reason, resources)
+
+	if len(migration.RemainingResources) == 0 {
+		logger.Info("recreating empty storage pool to match the spec")
+
+		err := linstorClient.Nodes.DeleteStoragePool(ctx, nodeName, fromSpec.StoragePoolName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete pool '%s' for migration: %w", fromSpec.StoragePoolName, err)
+		}
+
+		err = linstorClient.Nodes.CreateStoragePool(ctx, nodeName, *fromSpec)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recreate pool '%s': %w", fromSpec.StoragePoolName, err)
+		}
+
+		return nil, nil
+	}
+
+	if migration.Phase != shared.StoragePoolMigrationEvacuating {
+		logger.WithField("remaining", migration.RemainingResources).Warn("storage pool still contains resources, waiting for manual migration")
+
+		return migration, nil
+	}
+
+	// Replacement replicas are never placed on the same node, so pools with the same name on other nodes are fine.
+	progress, err := linstorClient.Evacuate(ctx, nodeName, lc.InStoragePool(fromSpec.StoragePoolName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to evacuate pool '%s': %w", fromSpec.StoragePoolName, err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"remaining": progress.Remaining,
+		"syncing":   progress.Syncing,
+	}).Info("evacuating storage pool")
+
+	return migration, nil
+}
+
+// removeStoragePool deletes a pool that was removed from the spec.
+//
+// Empty pools are deleted right away. If the pool still contains resources, they are moved to the remaining pools
+// first if requested by the removal policy. Returns the current state of the removal, or nil if the pool was deleted.
+func (r *ReconcileLinstorSatelliteSet) removeStoragePool(ctx context.Context, linstorClient *lc.HighLevelClient, satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName, poolName string) (*shared.StoragePoolMigrationStatus, error) {
+	logger := log.WithFields(logrus.Fields{
+		"Name":      satelliteSet.Name,
+		"Namespace": satelliteSet.Namespace,
+		"Node":      nodeName,
+		"Pool":      poolName,
+		"Op":        "removeStoragePool",
+	})
+
+	resources, err := linstorClient.GetAllResourcesOnNode(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch resources on node '%s': %w", nodeName, err)
+	}
+
+	drain := satelliteSet.Spec.StoragePoolRemovalPolicy == shared.StoragePoolRemovalDrain
+	removal := storagePoolMigrationStatus(drain, poolName, storagePoolRemovedReason, resources)
+
+	if len(removal.RemainingResources) == 0 {
+		// LINSTOR already ensures that the storage pool does not contain any resources
+		err := linstorClient.Nodes.DeleteStoragePool(ctx, nodeName, poolName)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	if !drain {
+		logger.WithField("remaining", removal.RemainingResources).Warn("storage pool still contains resources, waiting for manual removal")
+
+		return removal, nil
+	}
+
+	// The pool is removed from all nodes, so it should not receive any replacement replicas.
+	progress, err := linstorClient.Evacuate(ctx, nodeName, lc.InStoragePool(poolName), poolName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to drain pool '%s': %w", poolName, err)
+	}
+
+	logger.WithFields(logrus.Fields{
+		"remaining": progress.Remaining,
+		"syncing":   progress.Syncing,
+	}).Info("draining storage pool")
+
+	return removal, nil
+}
+
+// storagePoolMigrationStatus reports the migration of a pool, based on the diskful replicas still in the pool.
+func storagePoolMigrationStatus(evacuate bool, poolName, reason string, resources []lapi.ResourceWithVolumes) *shared.StoragePoolMigrationStatus {
+	phase := shared.StoragePoolMigrationPending
+	if evacuate {
+		phase = shared.StoragePoolMigrationEvacuating
+	}
+
+	migration := &shared.StoragePoolMigrationStatus{
+		Phase:  phase,
+		Reason: reason,
+	}
+
+	inPool := lc.InStoragePool(poolName)
+
+	for i := range resources {
+		if lc.IsDiskful(&resources[i]) && inPool(&resources[i]) {
+			migration.RemainingResources = append(migration.RemainingResources, resources[i].Name)
+		}
+	}
+
+	sort.Strings(migration.RemainingResources)
+
+	return migration
+}
 
-			// LINSTOR already ensures that the storage pool does not contain any resources
-			err := linstorClient.Nodes.DeleteStoragePool(ctx, pod.Spec.NodeName, existingPool.StoragePoolName)
-			if err != nil {
-				return err
-			}
+// reconcileStoragePoolProps updates the properties of an existing pool to match the spec. Properties previously set
+// by the operator, but no longer part of the spec, are removed.
+func reconcileStoragePoolProps(ctx context.Context, linstorClient *lc.HighLevelClient, nodeName string, existingPool, fromSpec *lapi.StoragePool) error {
+	overrideProps := make(map[string]string)
 
-			continue
+	for k, v := range fromSpec.Props {
+		existing, ok := existingPool.Props[k]
+		if !ok || existing != v {
+			overrideProps[k] = v
 		}
+	}
 
-		// TODO: Should we ever create a new v2 operator: Use admission controller to prevent mutating existing pools
-		fromSpec := matchingSpec.ToLinstorStoragePool()
-		existingMatchesSpec := fromSpec.ProviderKind != existingPool.ProviderKind
-
-		// We check that properties that are set from the spec are present and match.
-		// Any properties that are in LINSTOR but not in the spec are ignored.
-		for k, v := range fromSpec.Props {
-			existing, ok := existingPool.Props[k]
-			if !ok || existing != v {
-				existingMatchesSpec = false
-				break
-			}
-		}
+	var deleteProps []string
 
-		if existingMatchesSpec {
-			return fmt.Errorf("pool '%s' does not match the spec: existing: %+v, spec: %+v", existingPool.StoragePoolName, existingPool, fromSpec)
+	for _, k := range shared.ManagedPropertyKeys(existingPool.Props) {
+		if _, ok := fromSpec.Props[k]; !ok {
+			deleteProps = append(deleteProps, k)
 		}
 	}
 
-	for _, pool := range poolsFromSpec {
-		log.WithField("spec pool", pool).Debug("creating missing storage pool")
-		err := linstorClient.Nodes.CreateStoragePool(ctx, pod.Spec.NodeName, pool.ToLinstorStoragePool())
-		if err != nil {
-			return err
-		}
+	_, markerExists := existingPool.Props[kubeSpec.LinstorManagedPropertiesProperty]
+	_, markerWanted := fromSpec.Props[kubeSpec.LinstorManagedPropertiesProperty]
+
+	if markerExists && !markerWanted {
+		deleteProps = append(deleteProps, kubeSpec.LinstorManagedPropertiesProperty)
 	}
 
-	log.Debug("reconcile storage pools: finished")
+	if len(overrideProps) == 0 && len(deleteProps) == 0 {
+		return nil
+	}
+
+	log.WithFields(logrus.Fields{
+		"node":          nodeName,
+		"pool":          existingPool.StoragePoolName,
+		"overrideProps": overrideProps,
+		"deleteProps":   deleteProps,
+	}).Info("updating storage pool properties")
+
+	err := linstorClient.ModifyStoragePoolProps(ctx, nodeName, existingPool.StoragePoolName, lapi.GenericPropsModify{
+		OverrideProps: overrideProps,
+		DeleteProps:   deleteProps,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update properties of pool '%s': %w", existingPool.StoragePoolName, err)
+	}
 
 	return nil
 }
@@ -773,6 +1565,33 @@ func (r *ReconcileLinstorSatelliteSet) reconcileLinstorStatus(ctx context.Contex
 		log.Warnf("could not fetch nodes from LINSTOR: %v, continue with empty node list", err)
 	}
 
+	log.Debug("find all kubernetes nodes")
+
+	k8sNodes := &corev1.NodeList{}
+
+	err = r.client.List(ctx, k8sNodes)
+	if err != nil {
+		log.Warnf("could not fetch kubernetes nodes: %v, continue with empty node list", err)
+	}
+
+	// All resources are needed, as the resource health on a node depends on the replicas on other nodes.
+	log.Debug("find all resources")
+
+	resources, err := linstorClient.Resources.GetResourceView(ctx)
+	if err != nil && err != lapi.NotFoundError {
+		log.Warnf("could not fetch resources from LINSTOR: %v, continue with empty resource list", err)
+	}
+
+	previousPreflightChecks := make(map[string][]*shared.PreflightCheck, len(satelliteSet.Status.SatelliteStatuses))
+	previousPoolFailures := make(map[string]map[string]*shared.StoragePoolFailureStatus, len(satelliteSet.Status.SatelliteStatuses))
+
+	for _, status := range satelliteSet.Status.SatelliteStatuses {
+		if status != nil {
+			previousPreflightChecks[status.NodeName] = status.PreflightChecks
+			previousPoolFailures[status.NodeName] = storagePoolFailures(status)
+		}
+	}
+
 	satelliteSet.Status.SatelliteStatuses = make([]*shared.SatelliteStatus, len(pods))
 
 	for i := range pods {
@@ -793,7 +1612,26 @@ func (r *ReconcileLinstorSatelliteSet) reconcileLinstorStatus(ctx context.Contex
 			log.Warnf("failed to get storage pools for node %s: %v", pod.Spec.NodeName, err)
 		}
 
-		satelliteSet.Status.SatelliteStatuses[i] = satelliteStatusFromLinstor(pod, matchingNode, pools)
+		k8sNode := findK8sNode(k8sNodes.Items, pod.Spec.NodeName)
+
+		var declaredProps map[string]string
+		if k8sNode != nil {
+			declaredProps = nodePropertiesFromSpec(satelliteSet, k8sNode)
+		}
+
+		status := satelliteStatusFromLinstor(pod, matchingNode, pools, declaredProps)
+		status.KernelModule = kernelModuleStatus(satelliteSet.Spec.KernelModuleInjectionMode, pod, k8sNode, matchingNode)
+		status.PreflightChecks = preflightChecks(pod)
+		recordPreflightEvents(r.recorder, k8sNode, previousPreflightChecks[pod.Spec.NodeName], status.PreflightChecks)
+		reportStoragePoolMigrations(status, satelliteSet, pools, resources)
+		reportStoragePoolFailures(status, satelliteSet, pools, resources)
+
+		if matchingNode != nil {
+			status.ResourceHealth = resourceHealth(pod.Spec.NodeName, resources, resourceHealthDetailLimit(satelliteSet))
+		}
+		recordStoragePoolFailureEvents(r.recorder, satelliteSet, previousPoolFailures[pod.Spec.NodeName], status)
+
+		satelliteSet.Status.SatelliteStatuses[i] = status
 	}
 
 	// Sort for stable status reporting
@@ -804,7 +1642,9 @@ func (r *ReconcileLinstorSatelliteSet) reconcileLinstorStatus(ctx context.Contex
 	return nil
 }
 
-func satelliteStatusFromLinstor(pod *corev1.Pod, node *lapi.Node, pools []lapi.StoragePool) *shared.SatelliteStatus {
+// satelliteStatusFromLinstor reports the LINSTOR node of a satellite pod. Of the node properties, only those declared
+// in the spec are reported.
+func satelliteStatusFromLinstor(pod *corev1.Pod, node *lapi.Node, pools []lapi.StoragePool, declaredProps map[string]string) *shared.SatelliteStatus {
 	status := &shared.SatelliteStatus{
 		NodeStatus: shared.NodeStatus{
 			NodeName: pod.Spec.NodeName,
@@ -819,6 +1659,19 @@ func satelliteStatusFromLinstor(pod *corev1.Pod, node *lapi.Node, pools []lapi.S
 	status.ConnectionStatus = node.ConnectionStatus
 	status.RegisteredOnController = node.ConnectionStatus == lc.Online
 
+	for k := range declaredProps {
+		v, ok := node.Props[k]
+		if !ok {
+			continue
+		}
+
+		if status.Properties == nil {
+			status.Properties = make(map[string]string)
+		}
+
+		status.Properties[k] = v
+	}
+
 	poolsStatus := make([]*shared.StoragePoolStatus, 0)
 	for i := range pools {
 		poolsStatus = append(poolsStatus, shared.NewStoragePoolStatus(&pools[i]))
@@ -834,6 +1687,122 @@ func satelliteStatusFromLinstor(pod *corev1.Pod, node *lapi.Node, pools []lapi.S
 	return status
 }
 
+// kernelModuleStatus reports the DRBD kernel module on the node of a satellite pod.
+//
+// The version is taken from the termination message of the version report init container, errors from the kernel
+// module injector. If the injector did not fail, but LINSTOR can't use DRBD on the node, the reasons reported by
+// LINSTOR are used as error instead.
+func kernelModuleStatus(mode shared.KernelModuleInjectionMode, pod *corev1.Pod, k8sNode *corev1.Node, node *lapi.Node) *shared.KernelModuleStatus {
+	status := &shared.KernelModuleStatus{InjectionMode: mode}
+
+	if k8sNode != nil {
+		status.KernelVersion = k8sNode.Status.NodeInfo.KernelVersion
+	}
+
+	for i := range pod.Status.InitContainerStatuses {
+		containerStatus := &pod.Status.InitContainerStatuses[i]
+
+		switch containerStatus.Name {
+		case drbdVersionContainerName:
+			terminated := containerStatus.State.Terminated
+			if terminated != nil && terminated.ExitCode == 0 {
+				status.Version = drbdVersionFromProc(terminated.Message)
+			}
+		case kernelModuleInjectorContainerName:
+			status.Error = initContainerError(containerStatus)
+		}
+	}
+
+	if status.Error == "" && node != nil {
+		reasons := node.UnsupportedLayers[devicelayerkind.Drbd]
+		if len(reasons) != 0 {
+			status.Error = strings.Join(reasons, "; ")
+		}
+	}
+
+	return status
+}
+
+// drbdVersionFromProc returns the DRBD version from the first line of /proc/drbd, for example
+// "version: 9.0.30-1 (api:2/proto:86-120)".
+func drbdVersionFromProc(firstLine string) string {
+	fields := strings.Fields(firstLine)
+	if len(fields) < 2 || fields[0] != "version:" {
+		return ""
+	}
+
+	return fields[1]
+}
+
+// initContainerError returns the termination message of a failed init container, or "" if it did not fail.
+//
+// While a failed container is restarted, the message of the last failure is returned.
+func initContainerError(containerStatus *corev1.ContainerStatus) string {
+	terminated := containerStatus.State.Terminated
+	if terminated == nil {
+		terminated = containerStatus.LastTerminationState.Terminated
+	}
+
+	if terminated == nil || terminated.ExitCode == 0 {
+		return ""
+	}
+
+	message := strings.TrimSpace(terminated.Message)
+	if message == "" {
+		return fmt.Sprintf("%s: exit code %d", terminated.Reason, terminated.ExitCode)
+	}
+
+	return message
+}
+
+// reportStoragePoolMigrations adds the migration status to all storage pools that no longer match the spec or were
+// removed from the spec.
+func reportStoragePoolMigrations(status *shared.SatelliteStatus, satelliteSet *piraeusv1.LinstorSatelliteSet, pools []lapi.StoragePool, resources []lapi.ResourceWithVolumes) {
+	if satelliteSet.Spec.StoragePools == nil {
+		return
+	}
+
+	var nodeResources []lapi.ResourceWithVolumes
+
+	for i := range resources {
+		if resources[i].NodeName == status.NodeName {
+			nodeResources = append(nodeResources, resources[i])
+		}
+	}
+
+	specs := make(map[string]lapi.StoragePool)
+	for _, poolSpec := range satelliteSet.Spec.StoragePools.All() {
+		specs[poolSpec.GetName()] = poolSpec.ToLinstorStoragePool()
+	}
+
+	for i := range pools {
+		if pools[i].Props[kubeSpec.LinstorRegistrationProperty] != kubeSpec.Name {
+			continue
+		}
+
+		var migration *shared.StoragePoolMigrationStatus
+
+		fromSpec, ok := specs[pools[i].StoragePoolName]
+		if !ok {
+			drain := satelliteSet.Spec.StoragePoolRemovalPolicy == shared.StoragePoolRemovalDrain
+			migration = storagePoolMigrationStatus(drain, pools[i].StoragePoolName, storagePoolRemovedReason, nodeResources)
+		} else if reason := storagePoolMismatch(&pools[i], &fromSpec); reason != "" {
+			evacuate := satelliteSet.Spec.StoragePoolMigrationPolicy == shared.StoragePoolMigrationEvacuate
+			migration = storagePoolMigrationStatus(evacuate, pools[i].StoragePoolName, reason, nodeResources)
+		}
+
+		if migration == nil || len(migration.RemainingResources) == 0 {
+			continue
+		}
+
+		for _, poolStatus := range status.StoragePoolStatuses {
+			if poolStatus.Name == pools[i].StoragePoolName {
+				poolStatus.Migration = migration
+			}
+		}
+	}
+}
+
 func (r *ReconcileLinstorSatelliteSet) getAllNodePods(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet) ([]corev1.Pod, error) {
 	log.WithFields(logrus.Fields{
 		"Name":      satelliteSet.Name,
@@ -854,27 +1823,57 @@ func (r *ReconcileLinstorSatelliteSet) getAllNodePods(ctx context.Context, satel
 	return pods.Items, nil
 }
 
-func newSatelliteDaemonSet(satelliteSet *piraeusv1.LinstorSatelliteSet, satelliteCM, drbdReactorConfig *corev1.ConfigMap) *apps.DaemonSet {
+// newSatelliteDaemonSet returns the satellite DaemonSet for a kernel module injection group and node override. If
+// group or override are nil, the DaemonSet runs on all nodes without group or override.
+func newSatelliteDaemonSet(satelliteSet *piraeusv1.LinstorSatelliteSet, group *shared.KernelModuleInjectionImage, override *shared.SatelliteNodeOverride, satelliteCM, drbdReactorConfig *corev1.ConfigMap) *apps.DaemonSet {
 	var pullSecrets []corev1.LocalObjectReference
 	if satelliteSet.Spec.DrbdRepoCred != "" {
 		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: satelliteSet.Spec.DrbdRepoCred})
 	}
 
-	meta := getObjectMeta(satelliteSet, "%s-node")
+	meta := getObjectMeta(satelliteSet, satelliteDaemonSetName(group, override))
+	affinity := satelliteSet.Spec.Affinity
+	injectionImage := satelliteSet.Spec.KernelModuleInjectionImage
+
+	if group != nil {
+		meta.Labels[kubeSpec.KernelModuleInjectionGroupLabel] = group.Name
+		affinity = affinityWithNodeRequirement(affinity, corev1.NodeSelectorRequirement{
+			Key:      kubeSpec.KernelModuleInjectionGroupLabel,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{group.Name},
+		})
+		injectionImage = group.Image
+	} else if len(satelliteSet.Spec.KernelModuleInjectionImages) != 0 {
+		affinity = affinityWithNodeRequirement(affinity, corev1.NodeSelectorRequirement{
+			Key:      kubeSpec.KernelModuleInjectionGroupLabel,
+			Operator: corev1.NodeSelectorOpDoesNotExist,
+		})
+	}
+
+	if override != nil {
+		meta.Labels[kubeSpec.SatelliteNodeOverrideLabel] = override.Name
+		affinity = affinityWithNodeRequirement(affinity, corev1.NodeSelectorRequirement{
+			Key:      kubeSpec.SatelliteNodeOverrideLabel,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{override.Name},
+		})
+	} else if len(satelliteSet.Spec.NodeOverrides) != 0 {
+		affinity = affinityWithNodeRequirement(affinity, corev1.NodeSelectorRequirement{
+			Key:      kubeSpec.SatelliteNodeOverrideLabel,
+			Operator: corev1.NodeSelectorOpDoesNotExist,
+		})
+	}
+
 	ds := &apps.DaemonSet{
 		ObjectMeta: meta,
 		Spec: apps.DaemonSetSpec{
-			Selector: &metav1.LabelSelector{MatchLabels: meta.Labels},
-			UpdateStrategy: apps.DaemonSetUpdateStrategy{
-				Type: apps.RollingUpdateDaemonSetStrategyType,
-				RollingUpdate: &apps.RollingUpdateDaemonSet{
-					MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"},
-				},
-			},
+			Selector:       &metav1.LabelSelector{MatchLabels: meta.Labels},
+			UpdateStrategy: daemonSetUpdateStrategy(satelliteSet.Spec.UpdateStrategy),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: meta,
 				Spec: corev1.PodSpec{
-					Affinity:           satelliteSet.Spec.Affinity,
+					NodeSelector:       satelliteSet.Spec.NodeSelector,
+					Affinity:           affinity,
 					Tolerations:        satelliteSet.Spec.Tolerations,
 					HostNetwork:        true, // INFO: Per Roland, set to true
 					DNSPolicy:          corev1.DNSClusterFirstWithHostNet,
@@ -882,12 +1881,12 @@ func newSatelliteDaemonSet(satelliteSet *piraeusv1.LinstorSatelliteSet, satellit
 					ServiceAccountName: getServiceAccountName(satelliteSet),
 					Containers: []corev1.Container{
 						{
-							Name:  "linstor-satellite",
+							Name:  satelliteContainerName,
 							Image: satelliteSet.Spec.SatelliteImage,
 							Args: []string{
 								"startSatellite",
 							}, // Run linstor-satellite.
-							Env:             satelliteSet.Spec.AdditionalEnv,
+							Env:             satelliteEnv(satelliteSet),
 							ImagePullPolicy: satelliteSet.Spec.ImagePullPolicy,
 							SecurityContext: &corev1.SecurityContext{Privileged: &kubeSpec.Privileged},
 							Ports: []corev1.ContainerPort{
@@ -934,9 +1933,23 @@ func newSatelliteDaemonSet(satelliteSet *piraeusv1.LinstorSatelliteSet, satellit
 						{
 							Name: kubeSpec.LinstorConfDirName,
 							VolumeSource: corev1.VolumeSource{
-								ConfigMap: &corev1.ConfigMapVolumeSource{
-									LocalObjectReference: corev1.LocalObjectReference{
-										Name: satelliteCM.Name,
+								// The satellite configuration is stored in a secret of the same name.
+								Projected: &corev1.ProjectedVolumeSource{
+									Sources: []corev1.VolumeProjection{
+										{
+											ConfigMap: &corev1.ConfigMapProjection{
+												LocalObjectReference: corev1.LocalObjectReference{
+													Name: satelliteCM.Name,
+												},
+											},
+										},
+										{
+											Secret: &corev1.SecretProjection{
+												LocalObjectReference: corev1.LocalObjectReference{
+													Name: satelliteCM.Name,
+												},
+											},
+										},
 									},
 								},
 							},
@@ -974,10 +1987,14 @@ func newSatelliteDaemonSet(satelliteSet *piraeusv1.LinstorSatelliteSet, satellit
 		},
 	}
 
-	ds = daemonSetWithDRBDKernelModuleInjection(ds, satelliteSet)
+	ds = daemonSetWithDRBDKernelModuleInjection(ds, satelliteSet, injectionImage)
+	ds = daemonSetWithDRBDVersionReport(ds, satelliteSet)
+	ds = daemonSetWithPreflightChecks(ds, satelliteSet)
+	ds = daemonSetWithFileStoragePools(ds, satelliteSet)
 	ds = daemonsetWithMonitoringContainer(ds, satelliteSet, drbdReactorConfig)
 	ds = daemonSetWithSslConfiguration(ds, satelliteSet)
 	ds = daemonSetWithHttpsConfiguration(ds, satelliteSet)
+	ds = daemonSetWithNodeOverride(ds, override)
 	return ds
 }
 
@@ -986,33 +2003,62 @@ func daemonsetWithMonitoringContainer(ds *apps.DaemonSet, set *piraeusv1.Linstor
 		return ds
 	}
 
-	ds.Spec.Template.Spec.Containers = append(ds.Spec.Template.Spec.Containers, corev1.Container{
-		Name:            "drbd-prometheus-exporter",
-		Image:           set.Spec.MonitoringImage,
+	container := corev1.Container{
+		Name:            monitoringContainerName,
+		Image:           drbdReactorImage(set),
 		ImagePullPolicy: set.Spec.ImagePullPolicy,
-		Ports: []corev1.ContainerPort{
+		Command:         []string{"/bin/sh", "-c", drbdReactorReloadScript},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      kubeSpec.DrbdPrometheuscConfName,
+				MountPath: drbdReactorConfigDir,
+			},
+		},
+	}
+
+	if set.Spec.MonitoringImage != "" && set.Spec.MonitoringProxy != nil {
+		// The metrics are only reachable via the proxy, so the probe has to use the loopback address.
+		container.LivenessProbe = &corev1.Probe{
+			Handler: corev1.Handler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Host:   monitoringLoopbackHost(set),
+					Scheme: corev1.URISchemeHTTP,
+					Port:   intstr.FromInt(monitoringUpstreamPort),
+				},
+			},
+		}
+
+		ds.Spec.Template.Spec.Containers = append(ds.Spec.Template.Spec.Containers, newMonitoringProxyContainer(set))
+		ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, newMonitoringProxyVolume(set))
+	} else if set.Spec.MonitoringImage != "" {
+		container.Ports = []corev1.ContainerPort{
 			{
 				Name:          "prometheus",
 				ContainerPort: monitoringPort,
 				HostPort:      monitoringPort,
 				Protocol:      corev1.ProtocolTCP,
 			},
-		},
-		LivenessProbe: &corev1.Probe{
+		}
+		container.LivenessProbe = &corev1.Probe{
 			Handler: corev1.Handler{
 				HTTPGet: &corev1.HTTPGetAction{
 					Scheme: corev1.URISchemeHTTP,
 					Port:   intstr.FromInt(monitoringPort),
 				},
 			},
-		},
-		VolumeMounts: []corev1.VolumeMount{
-			{
-				Name:      kubeSpec.DrbdPrometheuscConfName,
-				MountPath: "/etc/drbd-reactor.d/",
-			},
-		},
-	})
+		}
+	}
+
+	if drbdReactorPrivileged(set) {
+		// Promoting resources and running handlers requires the same access to the host as the satellite.
+		container.SecurityContext = &corev1.SecurityContext{Privileged: &kubeSpec.Privileged}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      kubeSpec.DevDirName,
+			MountPath: kubeSpec.DevDir,
+		})
+	}
+
+	ds.Spec.Template.Spec.Containers = append(ds.Spec.Template.Spec.Containers, container)
 
 	ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, corev1.Volume{
 		Name: kubeSpec.DrbdPrometheuscConfName,
@@ -1028,7 +2074,39 @@ func daemonsetWithMonitoringContainer(ds *apps.DaemonSet, set *piraeusv1.Linstor
 	return ds
 }
 
+// satelliteEnv returns the environment of the satellite container. The additional environment from the spec takes
+// precedence.
+func satelliteEnv(satelliteSet *piraeusv1.LinstorSatelliteSet) []corev1.EnvVar {
+	var env []corev1.EnvVar
+
+	if javaOpts := satelliteSet.Spec.SslOptions.JavaOpts(); javaOpts != "" && !satelliteSet.Spec.SslConfig.IsPlain() {
+		env = append(env, corev1.EnvVar{Name: kubeSpec.JavaOptsName, Value: javaOpts})
+	}
+
+	return append(env, satelliteSet.Spec.AdditionalEnv...)
+}
+
+// newSatelliteConfigMap returns the client configuration for the satellite pods.
 func newSatelliteConfigMap(satelliteSet *piraeusv1.LinstorSatelliteSet) (*corev1.ConfigMap, error) {
+	clientConfig := lc.NewClientConfigForAPIResource(satelliteSet.Spec.ControllerEndpoint, &satelliteSet.Spec.LinstorClientConfig)
+	clientConfigFile, err := clientConfig.ToConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: getObjectMeta(satelliteSet, "%s-config"),
+		Data: map[string]string{
+			kubeSpec.LinstorClientConfigFile: clientConfigFile,
+		},
+	}
+
+	return cm, nil
+}
+
+// newSatelliteConfigSecret returns the satellite configuration. It is stored in a secret, as it contains the
+// keystore passwords.
+func newSatelliteConfigSecret(satelliteSet *piraeusv1.LinstorSatelliteSet, passwords *shared.LinstorSSLPasswords) (*corev1.Secret, error) {
 	// Create linstor satellite configuration
 	type SatelliteNetcomConfig struct {
 		Type                string `toml:"type,omitempty,omitzero"`
@@ -1053,54 +2131,55 @@ func newSatelliteConfigMap(satelliteSet *piraeusv1.LinstorSatelliteSet) (*corev1
 			Port:                satelliteSet.Spec.SslConfig.Port(),
 			ServerCertificate:   kubeSpec.LinstorSslDir + "/keystore.jks",
 			TrustedCertificates: kubeSpec.LinstorSslDir + "/certificates.jks",
-			// LINSTOR is currently limited on the controller side to these passwords. Because there is not much value
-			// in supporting different passwords just on one side of the connection, and because these passwords are in
-			// the "less secure" configmap anyways, we just use this password everywhere.
-			KeyPassword:        "linstor",
-			KeystorePassword:   "linstor",
-			TruststorePassword: "linstor",
-			SslProtocol:        "TLSv1.2",
+			KeyPassword:         passwords.Key,
+			KeystorePassword:    passwords.Keystore,
+			TruststorePassword:  passwords.Truststore,
+			SslProtocol:         satelliteSet.Spec.SslOptions.GetProtocol(),
 		}
 	}
 
-	// Create a config map from it
+	// Create a secret from it
 	tomlConfigBuilder := strings.Builder{}
 	tomlEncoder := toml.NewEncoder(&tomlConfigBuilder)
 	if err := tomlEncoder.Encode(config); err != nil {
 		return nil, err
 	}
 
-	clientConfig := lc.NewClientConfigForAPIResource(satelliteSet.Spec.ControllerEndpoint, &satelliteSet.Spec.LinstorClientConfig)
-	clientConfigFile, err := clientConfig.ToConfigFile()
-	if err != nil {
-		return nil, err
-	}
-
-	cm := &corev1.ConfigMap{
+	secret := &corev1.Secret{
 		ObjectMeta: getObjectMeta(satelliteSet, "%s-config"),
-		Data: map[string]string{
-			kubeSpec.LinstorSatelliteConfigFile: tomlConfigBuilder.String(),
-			kubeSpec.LinstorClientConfigFile:    clientConfigFile,
+		Type:       corev1.SecretTypeOpaque,
+		// Data instead of StringData: the API server converts StringData, so it would show up in every patch.
+		Data: map[string][]byte{
+			kubeSpec.LinstorSatelliteConfigFile: []byte(tomlConfigBuilder.String()),
 		},
 	}
 
-	return cm, nil
+	return secret, nil
 }
 
-func newMonitoringConfigMap(set *piraeusv1.LinstorSatelliteSet) *corev1.ConfigMap {
-	return &corev1.ConfigMap{
-		ObjectMeta: getObjectMeta(set, "%s-monitoring"),
-		Data: map[string]string{
-			"prometheus.toml": fmt.Sprintf(`
+// newMonitoringConfigMap returns the drbd-reactor configuration: the prometheus plugin if monitoring is enabled, and
+// all other configured plugins.
+func newMonitoringConfigMap(set *piraeusv1.LinstorSatelliteSet) (*corev1.ConfigMap, error) {
+	data, err := drbdReactorPluginConfig(set)
+	if err != nil {
+		return nil, err
+	}
+
+	if set.Spec.MonitoringImage != "" {
+		data["prometheus.toml"] = fmt.Sprintf(`
 [[prometheus]]
-address = "0.0.0.0:%d"
+address = "%s"
 enums = true
-`, monitoringPort),
-		},
+`, prometheusListenAddress(set))
 	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: getObjectMeta(set, "%s-monitoring"),
+		Data:       data,
+	}, nil
 }
 
-func daemonSetWithDRBDKernelModuleInjection(ds *apps.DaemonSet, satelliteSet *piraeusv1.LinstorSatelliteSet) *apps.DaemonSet {
+func daemonSetWithDRBDKernelModuleInjection(ds *apps.DaemonSet, satelliteSet *piraeusv1.LinstorSatelliteSet, image string) *apps.DaemonSet {
 	var kernelModHow string
 
 	mode := satelliteSet.Spec.KernelModuleInjectionMode
@@ -1135,11 +2214,13 @@ func daemonSetWithDRBDKernelModuleInjection(ds *apps.DaemonSet, satelliteSet *pi
 
 	ds.Spec.Template.Spec.InitContainers = []corev1.Container{
 		{
-			Name:            "kernel-module-injector",
-			Image:           satelliteSet.Spec.KernelModuleInjectionImage,
+			Name:            kernelModuleInjectorContainerName,
+			Image:           image,
 			ImagePullPolicy: satelliteSet.Spec.ImagePullPolicy,
 			SecurityContext: &corev1.SecurityContext{Privileged: &kubeSpec.Privileged},
 			Env:             env,
+			// Report the end of the log as termination message, so failures show up in the satellite status
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
 			VolumeMounts: []corev1.VolumeMount{
 				{
 					Name:      kubeSpec.SrcDirName,
@@ -1172,6 +2253,50 @@ func daemonSetWithDRBDKernelModuleInjection(ds *apps.DaemonSet, satelliteSet *pi
 	return ds
 }
 
+// daemonSetWithDRBDVersionReport adds an init container reporting the version of the loaded DRBD kernel module as
+// termination message. It runs after the kernel module injector, and never fails, even if no DRBD module is loaded.
+func daemonSetWithDRBDVersionReport(ds *apps.DaemonSet, satelliteSet *piraeusv1.LinstorSatelliteSet) *apps.DaemonSet {
+	ds.Spec.Template.Spec.InitContainers = append(ds.Spec.Template.Spec.InitContainers, corev1.Container{
+		Name:            drbdVersionContainerName,
+		Image:           satelliteSet.Spec.SatelliteImage,
+		ImagePullPolicy: satelliteSet.Spec.ImagePullPolicy,
+		Command: []string{
+			"sh", "-c",
+			fmt.Sprintf("if [ -e /proc/drbd ]; then head -n 1 /proc/drbd; fi > %s", corev1.TerminationMessagePathDefault),
+		},
+	})
+
+	return ds
+}
+
+func daemonSetWithFileStoragePools(ds *apps.DaemonSet, satelliteSet *piraeusv1.LinstorSatelliteSet) *apps.DaemonSet {
+	if satelliteSet.Spec.StoragePools == nil {
+		return ds
+	}
+
+	for i, dir := range satelliteSet.Spec.StoragePools.AllHostDirectories() {
+		name := fmt.Sprintf("%s-%d", kubeSpec.FileStoragePoolDirName, i)
+
+		// The backing files are referenced by path, so the directory is mounted at the same location as on the host.
+		ds.Spec.Template.Spec.Containers[0].VolumeMounts = append(ds.Spec.Template.Spec.Containers[0].VolumeMounts, corev1.VolumeMount{
+			Name:      name,
+			MountPath: dir,
+		})
+
+		ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name: name,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: dir,
+					Type: &kubeSpec.HostPathDirectoryOrCreateType,
+				},
+			},
+		})
+	}
+
+	return ds
+}
+
 func daemonSetWithSslConfiguration(ds *apps.DaemonSet, satelliteSet *piraeusv1.LinstorSatelliteSet) *apps.DaemonSet {
 	if satelliteSet.Spec.SslConfig.IsPlain() {
 		// TODO: Implement automatic SSL cert provisioning. For now we just disable SSL
@@ -1352,8 +2477,12 @@ func (r *ReconcileLinstorSatelliteSet) controllerReachable(ctx context.Context,
 	return err
 }
 
-// removeDanglingSatellites removes satellites that were registered by the operator and are no longer present.
-func (r *ReconcileLinstorSatelliteSet) removeDanglingSatellites(ctx context.Context, linstorClient *lc.HighLevelClient, k8sNodes []corev1.Node) error {
+// removeDanglingSatellites removes satellites that were registered by the set and are no longer present.
+//
+// Satellites are only removed once their kubernetes node is missing for longer than the configured grace period, so
+// that short re-registrations of a node don't trigger evictions. All satellites pending removal are reported in the
+// status.
+func (r *ReconcileLinstorSatelliteSet) removeDanglingSatellites(ctx context.Context, linstorClient *lc.HighLevelClient, satelliteSet *piraeusv1.LinstorSatelliteSet, k8sNodes []corev1.Node) error {
 	lnodes, err := linstorClient.Nodes.GetAll(ctx, &lapi.ListOpts{
 		Prop: []string{fmt.Sprintf("%s=%s", kubeSpec.LinstorRegistrationProperty, kubeSpec.Name)},
 	})
@@ -1361,12 +2490,24 @@ func (r *ReconcileLinstorSatelliteSet) removeDanglingSatellites(ctx context.Cont
 		return fmt.Errorf("failed to list nodes")
 	}
 
+	previous := make(map[string]*piraeusv1.DanglingSatelliteStatus)
+	for _, dangling := range satelliteSet.Status.DanglingSatellites {
+		previous[dangling.NodeName] = dangling
+	}
+
+	gracePeriod := satelliteSet.Spec.DanglingSatelliteGracePeriod.Duration
+	policy := satelliteSet.Spec.DanglingSatellitePolicy
+
+	var danglingSatellites []*piraeusv1.DanglingSatelliteStatus
+
+	var errs []error
+
 	for i := range lnodes {
 		node := &lnodes[i]
 
 		log := log.WithField("node", node.Name)
 
-		if node.Type != lc.Satellite {
+		if node.Type != lc.Satellite || !ownedBySatelliteSet(node, satelliteSet) {
 			continue
 		}
 
@@ -1378,27 +2519,82 @@ func (r *ReconcileLinstorSatelliteSet) removeDanglingSatellites(ctx context.Cont
 			continue
 		}
 
-		log.Debug("node does not exist in kubernetes, evicting")
+		dangling, ok := previous[node.Name]
+		if !ok {
+			log.Info("node does not exist in kubernetes, waiting for grace period")
+
+			dangling = &piraeusv1.DanglingSatelliteStatus{NodeName: node.Name, Since: metav1.Now()}
+
+			r.recorder.Eventf(satelliteSet, corev1.EventTypeWarning, "SatelliteMissing", "Kubernetes node for satellite '%s' is missing, removal pending", node.Name)
+		}
+
+		danglingSatellites = append(danglingSatellites, dangling)
 
 		if node.ConnectionStatus != lc.Offline {
-			return fmt.Errorf("online satellite registered by operator without associated k8s node")
+			dangling.Phase = piraeusv1.DanglingSatelliteOnline
+
+			errs = append(errs, fmt.Errorf("online satellite '%s' registered by operator without associated k8s node", node.Name))
+
+			continue
+		}
+
+		if time.Since(dangling.Since.Time) < gracePeriod {
+			dangling.Phase = piraeusv1.DanglingSatellitePending
+
+			continue
+		}
+
+		if policy == shared.DanglingSatelliteReportOnly {
+			if dangling.Phase != piraeusv1.DanglingSatelliteReported {
+				r.recorder.Eventf(satelliteSet, corev1.EventTypeWarning, "SatelliteMissing", "Kubernetes node for satellite '%s' is missing for more than %s, needs to be removed manually", node.Name, gracePeriod)
+			}
+
+			dangling.Phase = piraeusv1.DanglingSatelliteReported
+
+			continue
 		}
 
+		log.Debug("node does not exist in kubernetes, evicting")
+
 		err := linstorClient.Nodes.Evict(ctx, node.Name)
 		if err != nil {
-			return fmt.Errorf("failed to evict node '%s': %w", node.Name, err)
+			errs = append(errs, fmt.Errorf("failed to evict node '%s': %w", node.Name, err))
+
+			continue
+		}
+
+		if dangling.Phase != piraeusv1.DanglingSatelliteEvicted {
+			r.recorder.Eventf(satelliteSet, corev1.EventTypeNormal, "SatelliteEvicted", "Evicted satellite '%s' after its kubernetes node was removed", node.Name)
 		}
 
-		if mdutil.SliceContains(node.Flags, linstor.FlagEvicted) {
+		dangling.Phase = piraeusv1.DanglingSatelliteEvicted
+
+		if policy == shared.DanglingSatelliteEvictAndLost && mdutil.SliceContains(node.Flags, linstor.FlagEvicted) {
 			log.Debug("node evicted, deleting")
 
 			err := linstorClient.Nodes.Lost(ctx, node.Name)
 			if err != nil {
-				return fmt.Errorf("failed to delete node '%s': %w", node.Name, err)
+				errs = append(errs, fmt.Errorf("failed to delete node '%s': %w", node.Name, err))
+
+				continue
 			}
+
+			r.recorder.Eventf(satelliteSet, corev1.EventTypeNormal, "SatelliteLost", "Removed evicted satellite '%s'", node.Name)
+
+			danglingSatellites = danglingSatellites[:len(danglingSatellites)-1]
 		}
 	}
 
+	sort.Slice(danglingSatellites, func(i, j int) bool {
+		return danglingSatellites[i].NodeName < danglingSatellites[j].NodeName
+	})
+
+	satelliteSet.Status.DanglingSatellites = danglingSatellites
+
+	if len(errs) != 0 {
+		return &reconcileutil.CombinedError{Sources: errs}
+	}
+
 	return nil
 }
 
@@ -1424,13 +2620,18 @@ func getObjectMeta(satelliteSet *piraeusv1.LinstorSatelliteSet, nameFmt string)
 	}
 }
 
-func nodeLabelsToProps(labels map[string]string) map[string]string {
+func nodeLabelsToProps(labels map[string]string, sync *shared.NodeLabelSync) map[string]string {
 	result := map[string]string{
 		kubeSpec.LinstorRegistrationProperty: kubeSpec.Name,
 	}
 
 	for k, v := range labels {
-		result[fmt.Sprintf("%s/%s", linstor.NamespcAuxiliary, k)] = v
+		name, ok := sync.PropertyName(k)
+		if !ok {
+			continue
+		}
+
+		result[fmt.Sprintf("%s/%s", linstor.NamespcAuxiliary, name)] = v
 	}
 
 	return result
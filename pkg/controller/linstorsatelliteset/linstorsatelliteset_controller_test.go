@@ -0,0 +1,961 @@
+package linstorsatelliteset
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	linstor "github.com/LINBIT/golinstor"
+	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/LINBIT/golinstor/devicelayerkind"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis"
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	"github.com/piraeusdatastore/piraeus-operator/pkg/k8s/reconcileutil"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+func TestKernelModuleStatus(t *testing.T) {
+	k8sNode := &corev1.Node{
+		Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KernelVersion: "5.4.0-90-generic"}},
+	}
+
+	terminated := func(name string, exitCode int32, message string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{
+			Name:  name,
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Reason: "Error", Message: message}},
+		}
+	}
+
+	crashLooping := corev1.ContainerStatus{
+		Name:                 kernelModuleInjectorContainerName,
+		State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: "Could not find kernel headers\n"}},
+	}
+
+	testcases := []struct {
+		name     string
+		statuses []corev1.ContainerStatus
+		node     *lapi.Node
+		expected *shared.KernelModuleStatus
+	}{
+		{
+			name: "loaded",
+			statuses: []corev1.ContainerStatus{
+				terminated(kernelModuleInjectorContainerName, 0, ""),
+				terminated(drbdVersionContainerName, 0, "version: 9.0.30-1 (api:2/proto:86-120)\n"),
+			},
+			node: &lapi.Node{},
+			expected: &shared.KernelModuleStatus{
+				Version:       "9.0.30-1",
+				InjectionMode: shared.ModuleInjectionCompile,
+				KernelVersion: "5.4.0-90-generic",
+			},
+		},
+		{
+			name:     "injector-crashlooping",
+			statuses: []corev1.ContainerStatus{crashLooping},
+			expected: &shared.KernelModuleStatus{
+				InjectionMode: shared.ModuleInjectionCompile,
+				KernelVersion: "5.4.0-90-generic",
+				Error:         "Could not find kernel headers",
+			},
+		},
+		{
+			name:     "injector-failed-without-message",
+			statuses: []corev1.ContainerStatus{terminated(kernelModuleInjectorContainerName, 2, "")},
+			expected: &shared.KernelModuleStatus{
+				InjectionMode: shared.ModuleInjectionCompile,
+				KernelVersion: "5.4.0-90-generic",
+				Error:         "Error: exit code 2",
+			},
+		},
+		{
+			name: "not-loaded",
+			statuses: []corev1.ContainerStatus{
+				terminated(kernelModuleInjectorContainerName, 0, ""),
+				terminated(drbdVersionContainerName, 0, ""),
+			},
+			node: &lapi.Node{
+				UnsupportedLayers: map[devicelayerkind.DeviceLayerKind][]string{
+					devicelayerkind.Drbd: {"DRBD version has to be >= 9. Current DRBD version: 8.4.11"},
+				},
+			},
+			expected: &shared.KernelModuleStatus{
+				InjectionMode: shared.ModuleInjectionCompile,
+				KernelVersion: "5.4.0-90-generic",
+				Error:         "DRBD version has to be >= 9. Current DRBD version: 8.4.11",
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: corev1.PodStatus{InitContainerStatuses: tcase.statuses}}
+
+			actual := kernelModuleStatus(shared.ModuleInjectionCompile, pod, k8sNode, tcase.node)
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %+v, actual: %+v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDrbdVersionFromProc(t *testing.T) {
+	testcases := map[string]string{
+		"version: 9.0.30-1 (api:2/proto:86-120)": "9.0.30-1",
+		"version: 8.4.11 (api:1/proto:86-101)\n": "8.4.11",
+		"":                                       "",
+		"unexpected output":                      "",
+	}
+
+	for line, expected := range testcases {
+		actual := drbdVersionFromProc(line)
+		if actual != expected {
+			t.Errorf("%q: expected: %q, actual: %q", line, expected, actual)
+		}
+	}
+}
+
+func TestAffinityWithNodeRequirement(t *testing.T) {
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      "example.com/group",
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{"a"},
+	}
+
+	zone := corev1.NodeSelectorRequirement{
+		Key:      "topology.kubernetes.io/zone",
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{"z1"},
+	}
+
+	userAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{MatchExpressions: []corev1.NodeSelectorRequirement{zone}},
+					{MatchFields: []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-1"}}}},
+				},
+			},
+		},
+	}
+
+	actual := affinityWithNodeRequirement(userAffinity, requirement)
+	for i, term := range actual.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		last := term.MatchExpressions[len(term.MatchExpressions)-1]
+		if !reflect.DeepEqual(requirement, last) {
+			t.Errorf("term %d: expected requirement to be added, actual: %+v", i, term)
+		}
+	}
+
+	if len(userAffinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions) != 1 {
+		t.Errorf("expected original affinity to be unchanged")
+	}
+
+	fromNil := affinityWithNodeRequirement(nil, requirement)
+	expected := []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{requirement}}}
+
+	if !reflect.DeepEqual(expected, fromNil.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms) {
+		t.Errorf("expected: %+v, actual: %+v", expected, fromNil.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+	}
+}
+
+func TestNewSatelliteDaemonSetsWithNodeOverrides(t *testing.T) {
+	largeResources := corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("8Gi")}}
+	injectorResources := corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}}
+
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns", Namespace: "piraeus"},
+		Spec: piraeusv1.LinstorSatelliteSetSpec{
+			KernelModuleInjectionMode:   shared.ModuleInjectionCompile,
+			KernelModuleInjectionImages: []*shared.KernelModuleInjectionImage{{Name: "focal", Image: "drbd9-focal"}},
+			NodeOverrides: []*shared.SatelliteNodeOverride{
+				{
+					Name:                           "large",
+					NodeSelector:                   map[string]string{"example.com/size": "large"},
+					Resources:                      &largeResources,
+					KernelModuleInjectionResources: &injectorResources,
+					JavaOpts:                       "-Xmx4g",
+				},
+			},
+		},
+	}
+
+	satelliteCM := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns-config"}}
+
+	daemonSets := newSatelliteDaemonSets(satelliteSet, satelliteCM, nil)
+
+	byName := make(map[string]*apps.DaemonSet, len(daemonSets))
+	for _, ds := range daemonSets {
+		byName[ds.Name] = ds
+	}
+
+	for _, name := range []string{"piraeus-ns-node", "piraeus-ns-node-large", "piraeus-ns-node-focal", "piraeus-ns-node-focal-large"} {
+		if byName[name] == nil {
+			t.Errorf("expected daemonset '%s', got %v", name, byName)
+		}
+	}
+
+	hasRequirement := func(ds *apps.DaemonSet, expected corev1.NodeSelectorRequirement) bool {
+		for _, term := range ds.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+			for _, req := range term.MatchExpressions {
+				if reflect.DeepEqual(req, expected) {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	withoutOverride := corev1.NodeSelectorRequirement{Key: kubeSpec.SatelliteNodeOverrideLabel, Operator: corev1.NodeSelectorOpDoesNotExist}
+	if ds := byName["piraeus-ns-node"]; ds != nil && !hasRequirement(ds, withoutOverride) {
+		t.Errorf("expected default daemonset to exclude nodes with override")
+	}
+
+	withOverride := corev1.NodeSelectorRequirement{Key: kubeSpec.SatelliteNodeOverrideLabel, Operator: corev1.NodeSelectorOpIn, Values: []string{"large"}}
+
+	ds := byName["piraeus-ns-node-focal-large"]
+	if ds == nil {
+		t.FailNow()
+	}
+
+	if !hasRequirement(ds, withOverride) || ds.Labels[kubeSpec.KernelModuleInjectionGroupLabel] != "focal" || ds.Labels[kubeSpec.SatelliteNodeOverrideLabel] != "large" {
+		t.Errorf("expected daemonset restricted to injection group and override, got %+v", ds.ObjectMeta)
+	}
+
+	satellite := ds.Spec.Template.Spec.Containers[0]
+	if !reflect.DeepEqual(satellite.Resources, largeResources) {
+		t.Errorf("expected satellite resources from override, got %+v", satellite.Resources)
+	}
+
+	if len(satellite.Env) != 1 || satellite.Env[0].Name != kubeSpec.JavaOptsName || satellite.Env[0].Value != "-Xmx4g" {
+		t.Errorf("expected java options from override, got %+v", satellite.Env)
+	}
+
+	injectorFound := false
+
+	for _, container := range ds.Spec.Template.Spec.InitContainers {
+		if container.Name != kernelModuleInjectorContainerName {
+			continue
+		}
+
+		injectorFound = true
+
+		if !reflect.DeepEqual(container.Resources, injectorResources) {
+			t.Errorf("expected injector resources from override, got %+v", container.Resources)
+		}
+	}
+
+	if !injectorFound {
+		t.Errorf("expected kernel module injector container")
+	}
+
+	if defaultSatellite := byName["piraeus-ns-node"].Spec.Template.Spec.Containers[0]; len(defaultSatellite.Env) != 0 || len(defaultSatellite.Resources.Limits) != 0 {
+		t.Errorf("expected default daemonset without override, got %+v", defaultSatellite)
+	}
+}
+
+func TestValidateNodeGroupNames(t *testing.T) {
+	valid := &piraeusv1.LinstorSatelliteSet{
+		Spec: piraeusv1.LinstorSatelliteSetSpec{
+			KernelModuleInjectionImages: []*shared.KernelModuleInjectionImage{{Name: "focal"}},
+			NodeOverrides:               []*shared.SatelliteNodeOverride{{Name: "large"}},
+		},
+	}
+	if err := validateNodeGroupNames(valid); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	conflict := &piraeusv1.LinstorSatelliteSet{
+		Spec: piraeusv1.LinstorSatelliteSetSpec{
+			KernelModuleInjectionImages: []*shared.KernelModuleInjectionImage{{Name: "focal"}, {Name: "focal-large"}},
+			NodeOverrides:               []*shared.SatelliteNodeOverride{{Name: "large"}},
+		},
+	}
+	if err := validateNodeGroupNames(conflict); err == nil {
+		t.Errorf("expected error for conflicting daemonset names")
+	}
+}
+
+func TestEnvWithJavaOpts(t *testing.T) {
+	testcases := []struct {
+		name     string
+		env      []corev1.EnvVar
+		expected []corev1.EnvVar
+	}{
+		{
+			name:     "empty",
+			expected: []corev1.EnvVar{{Name: kubeSpec.JavaOptsName, Value: "-Xmx4g"}},
+		},
+		{
+			name:     "append",
+			env:      []corev1.EnvVar{{Name: kubeSpec.JavaOptsName, Value: "-Dfoo=bar"}, {Name: "EXTRA", Value: "1"}},
+			expected: []corev1.EnvVar{{Name: kubeSpec.JavaOptsName, Value: "-Dfoo=bar -Xmx4g"}, {Name: "EXTRA", Value: "1"}},
+		},
+		{
+			name:     "prepend",
+			env:      []corev1.EnvVar{{Name: "EXTRA", Value: "1"}},
+			expected: []corev1.EnvVar{{Name: kubeSpec.JavaOptsName, Value: "-Xmx4g"}, {Name: "EXTRA", Value: "1"}},
+		},
+	}
+
+	for _, tcase := range testcases {
+		tcase := tcase
+
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := envWithJavaOpts(tcase.env, "-Xmx4g")
+			if !reflect.DeepEqual(actual, tcase.expected) {
+				t.Errorf("expected %+v, got %+v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPreflightInitContainerOrder(t *testing.T) {
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns", Namespace: "piraeus"},
+		Spec: piraeusv1.LinstorSatelliteSetSpec{
+			KernelModuleInjectionMode: shared.ModuleInjectionCompile,
+			PreflightPolicy:           shared.PreflightBlock,
+		},
+	}
+
+	satelliteCM := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns-config"}}
+
+	ds := newSatelliteDaemonSet(satelliteSet, nil, nil, satelliteCM, nil)
+
+	var actual []string
+	for _, container := range ds.Spec.Template.Spec.InitContainers {
+		actual = append(actual, container.Name)
+	}
+
+	expected := []string{preflightContainerName, kernelModuleInjectorContainerName, drbdVersionContainerName, preflightDRBDContainerName}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected init containers %v, got %v", expected, actual)
+	}
+}
+
+func TestPreflightChecks(t *testing.T) {
+	results := `[{"name":"UsermodeHelper","result":"Fail","message":"DRBD usermode_helper is /sbin/drbdadm, needs to be disabled"},{"name":"Multipath","result":"Pass","message":""}]
+`
+	expected := []*shared.PreflightCheck{
+		{Name: "UsermodeHelper", Result: shared.PreflightFail, Message: "DRBD usermode_helper is /sbin/drbdadm, needs to be disabled"},
+		{Name: "Multipath", Result: shared.PreflightPass},
+	}
+
+	drbdResults := `[{"name":"UsermodeHelper","result":"Pass","message":""}]
+`
+
+	testcases := []struct {
+		name       string
+		status     corev1.ContainerStatus
+		drbdStatus *corev1.ContainerStatus
+		expected   []*shared.PreflightCheck
+	}{
+		{
+			name: "passed",
+			status: corev1.ContainerStatus{
+				Name:  preflightContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: results}},
+			},
+			expected: expected,
+		},
+		{
+			name: "blocking",
+			status: corev1.ContainerStatus{
+				Name:                 preflightContainerName,
+				State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Message: results}},
+			},
+			expected: expected,
+		},
+		{
+			name: "with-drbd-checks",
+			status: corev1.ContainerStatus{
+				Name:  preflightContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: results}},
+			},
+			drbdStatus: &corev1.ContainerStatus{
+				Name:  preflightDRBDContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: drbdResults}},
+			},
+			expected: append(expected, &shared.PreflightCheck{Name: "UsermodeHelper", Result: shared.PreflightPass}),
+		},
+		{
+			name: "waiting-for-drbd-checks",
+			status: corev1.ContainerStatus{
+				Name:  preflightContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: results}},
+			},
+			drbdStatus: &corev1.ContainerStatus{
+				Name:  preflightDRBDContainerName,
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}},
+			},
+			expected: expected,
+		},
+		{
+			name: "running",
+			status: corev1.ContainerStatus{
+				Name:  preflightContainerName,
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			},
+		},
+		{
+			name: "invalid-message",
+			status: corev1.ContainerStatus{
+				Name:  preflightContainerName,
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: "sh: not found"}},
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			pod := &corev1.Pod{Status: corev1.PodStatus{InitContainerStatuses: []corev1.ContainerStatus{tcase.status}}}
+			if tcase.drbdStatus != nil {
+				pod.Status.InitContainerStatuses = append(pod.Status.InitContainerStatuses, *tcase.drbdStatus)
+			}
+
+			actual := preflightChecks(pod)
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %+v, actual: %+v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecordPreflightEvents(t *testing.T) {
+	k8sNode := &corev1.Node{}
+
+	passed := []*shared.PreflightCheck{{Name: "UsermodeHelper", Result: shared.PreflightPass}}
+	failed := []*shared.PreflightCheck{
+		{Name: "UsermodeHelper", Result: shared.PreflightFail, Message: "not disabled"},
+		{Name: "LVMFilter", Result: shared.PreflightWarn, Message: "no filter"},
+	}
+
+	testcases := []struct {
+		name     string
+		previous []*shared.PreflightCheck
+		current  []*shared.PreflightCheck
+		expected []string
+	}{
+		{name: "passed", current: passed},
+		{name: "unchanged", previous: failed, current: failed},
+		{name: "not-reported", previous: failed},
+		{
+			name:     "failed",
+			previous: passed,
+			current:  failed,
+			expected: []string{
+				"Warning PreflightCheckFailed DRBD preflight checks failed: UsermodeHelper: not disabled",
+				"Warning PreflightCheckWarning DRBD preflight checks reported warnings: LVMFilter: no filter",
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+
+			recordPreflightEvents(recorder, k8sNode, tcase.previous, tcase.current)
+			close(recorder.Events)
+
+			var actual []string
+			for event := range recorder.Events {
+				actual = append(actual, event)
+			}
+
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %v, actual: %v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSatelliteConfig(t *testing.T) {
+	sslSecret := shared.LinstorSSLConfig("node-secret")
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{
+		Spec: piraeusv1.LinstorSatelliteSetSpec{
+			SslConfig: &sslSecret,
+			SslOptions: &shared.LinstorSSLOptions{
+				Protocol: "TLSv1.3",
+				Ciphers:  []string{"TLS_AES_256_GCM_SHA384"},
+			},
+			AdditionalEnv: []corev1.EnvVar{{Name: "EXTRA", Value: "1"}},
+		},
+	}
+
+	cm, err := newSatelliteConfigMap(satelliteSet)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := cm.Data[kubeSpec.LinstorSatelliteConfigFile]; ok {
+		t.Errorf("expected satellite config not to be stored in configmap")
+	}
+
+	secret, err := newSatelliteConfigSecret(satelliteSet, &shared.LinstorSSLPasswords{Keystore: "store", Key: "key", Truststore: "trust"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config := string(secret.Data[kubeSpec.LinstorSatelliteConfigFile])
+	for _, expected := range []string{`keystore_password = "store"`, `key_password = "key"`, `truststore_password = "trust"`, `ssl_protocol = "TLSv1.3"`} {
+		if !strings.Contains(config, expected) {
+			t.Errorf("expected satellite config to contain '%s', got:\n%s", expected, config)
+		}
+	}
+
+	env := satelliteEnv(satelliteSet)
+	if len(env) != 2 || env[0].Name != kubeSpec.JavaOptsName || env[1].Name != "EXTRA" {
+		t.Errorf("expected java options followed by additional env, got %+v", env)
+	}
+}
+
+// apiServerSecretClient handles secrets the way the API server does: StringData is merged into Data and never
+// returned.
+type apiServerSecretClient struct {
+	client.Client
+}
+
+func (c *apiServerSecretClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	err := c.Client.Get(ctx, key, obj)
+
+	if secret, ok := obj.(*corev1.Secret); ok {
+		secret.StringData = nil
+	}
+
+	return err
+}
+
+func (c *apiServerSecretClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || len(secret.StringData) == 0 {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+
+	stored := secret.DeepCopy()
+	if stored.Data == nil {
+		stored.Data = make(map[string][]byte)
+	}
+
+	for k, v := range stored.StringData {
+		stored.Data[k] = []byte(v)
+	}
+
+	stored.StringData = nil
+
+	return c.Client.Create(ctx, stored, opts...)
+}
+
+func TestSatelliteConfigSecretUnchanged(t *testing.T) {
+	err := apis.AddToScheme(scheme.Scheme)
+	if err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns", Namespace: "piraeus", UID: "1234"},
+	}
+
+	kubeClient := &apiServerSecretClient{Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()}
+
+	for i, expected := range []bool{true, false} {
+		secret, err := newSatelliteConfigSecret(satelliteSet, &shared.LinstorSSLPasswords{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		changed, err := reconcileutil.CreateOrUpdateWithOwner(context.Background(), kubeClient, scheme.Scheme, secret, satelliteSet, reconcileutil.OnPatchErrorReturn)
+		if err != nil {
+			t.Fatalf("unexpected error applying secret: %v", err)
+		}
+
+		if changed != expected {
+			t.Errorf("apply #%d: expected changed=%t, got %t", i+1, expected, changed)
+		}
+	}
+}
+
+func TestSatelliteStatusFromLinstor(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node-1"}}
+	node := &lapi.Node{
+		Name:             "node-1",
+		ConnectionStatus: lc.Online,
+		Props: map[string]string{
+			kubeSpec.LinstorRegistrationProperty:      kubeSpec.Name,
+			kubeSpec.LinstorManagedPropertiesProperty: "Aux/site,DrbdOptions/Net/max-buffers",
+			"Aux/site":                    "main",
+			"DrbdOptions/Net/max-buffers": "8000",
+			"CurStltConnName":             "default",
+		},
+	}
+
+	status := satelliteStatusFromLinstor(pod, node, nil, map[string]string{
+		"Aux/site":                    "main",
+		"DrbdOptions/Net/max-buffers": "8000",
+		"Aux/not-yet-set":             "1",
+	})
+
+	expected := map[string]string{
+		"Aux/site":                    "main",
+		"DrbdOptions/Net/max-buffers": "8000",
+	}
+
+	if !reflect.DeepEqual(expected, status.Properties) {
+		t.Errorf("expected properties %v, got %v", expected, status.Properties)
+	}
+
+	status = satelliteStatusFromLinstor(pod, node, nil, nil)
+	if status.Properties != nil {
+		t.Errorf("expected no properties without declared properties, got %v", status.Properties)
+	}
+}
+
+func TestRemoveStoragePool(t *testing.T) {
+	replica := func(name, node, pool string) lapi.ResourceWithVolumes {
+		return lapi.ResourceWithVolumes{
+			Resource: lapi.Resource{Name: name, NodeName: node},
+			Volumes: []lapi.Volume{
+				{StoragePoolName: pool, State: lapi.VolumeState{DiskState: lc.DiskStateUpToDate}},
+			},
+		}
+	}
+
+	testcases := []struct {
+		name             string
+		policy           shared.StoragePoolRemovalPolicy
+		resources        []lapi.ResourceWithVolumes
+		expectedPhase    string
+		expectedRequests []string
+	}{
+		{
+			name:   "empty-pool-with-replicas-on-other-nodes",
+			policy: shared.StoragePoolRemovalManual,
+			resources: []lapi.ResourceWithVolumes{
+				replica("pvc-1", "node-2", "old"),
+			},
+			expectedRequests: []string{"DELETE /v1/nodes/node-1/storage-pools/old"},
+		},
+		{
+			name:   "manual",
+			policy: shared.StoragePoolRemovalManual,
+			resources: []lapi.ResourceWithVolumes{
+				replica("pvc-1", "node-1", "old"),
+				replica("pvc-1", "node-2", "new"),
+			},
+			expectedPhase: shared.StoragePoolMigrationPending,
+		},
+		{
+			name:   "drain",
+			policy: shared.StoragePoolRemovalDrain,
+			resources: []lapi.ResourceWithVolumes{
+				replica("pvc-1", "node-1", "old"),
+				replica("pvc-1", "node-2", "new"),
+			},
+			expectedPhase: shared.StoragePoolMigrationEvacuating,
+			expectedRequests: []string{
+				"PUT /v1/resource-definitions/pvc-1/resources/node-1",
+				"POST /v1/resource-definitions/pvc-1/autoplace",
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			var requests []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/view/resources":
+					_ = json.NewEncoder(w).Encode(tcase.resources)
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/view/storage-pools":
+					_ = json.NewEncoder(w).Encode([]lapi.StoragePool{
+						{StoragePoolName: "old", ProviderKind: lapi.LVM},
+						{StoragePoolName: "new", ProviderKind: lapi.LVM},
+					})
+				default:
+					requests = append(requests, r.Method+" "+r.URL.Path)
+					_, _ = w.Write([]byte("[]"))
+				}
+			}))
+			defer server.Close()
+
+			linstorClient, err := lc.NewHighLevelLinstorClientFromConfig(server.URL, &shared.LinstorClientConfig{}, nil)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			satelliteSet := &piraeusv1.LinstorSatelliteSet{
+				Spec: piraeusv1.LinstorSatelliteSetSpec{StoragePoolRemovalPolicy: tcase.policy},
+			}
+
+			r := &ReconcileLinstorSatelliteSet{}
+
+			removal, err := r.removeStoragePool(context.Background(), linstorClient, satelliteSet, "node-1", "old")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tcase.expectedPhase == "" && removal != nil {
+				t.Errorf("expected pool to be removed, got %+v", removal)
+			}
+
+			if tcase.expectedPhase != "" && (removal == nil || removal.Phase != tcase.expectedPhase || !reflect.DeepEqual(removal.RemainingResources, []string{"pvc-1"})) {
+				t.Errorf("expected pool removal in phase '%s', got %+v", tcase.expectedPhase, removal)
+			}
+
+			if !reflect.DeepEqual(tcase.expectedRequests, requests) {
+				t.Errorf("expected requests %v, got %v", tcase.expectedRequests, requests)
+			}
+		})
+	}
+}
+
+func TestReconcileNodeGroups(t *testing.T) {
+	err := apis.AddToScheme(scheme.Scheme)
+	if err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+
+	testcases := []struct {
+		name     string
+		strategy piraeusv1.SatelliteUpdateStrategyType
+		// Expected injection group and node override label per node.
+		expected map[string]string
+	}{
+		{
+			name:     "rolling-update",
+			strategy: piraeusv1.SatelliteUpdateRollingUpdate,
+			expected: map[string]string{"node-with-satellite": "all", "new-node": "all"},
+		},
+		{
+			name:     "managed",
+			strategy: piraeusv1.SatelliteUpdateManaged,
+			expected: map[string]string{"node-with-satellite": "", "new-node": "all"},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			satelliteSet := &piraeusv1.LinstorSatelliteSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns", Namespace: "piraeus"},
+				Spec: piraeusv1.LinstorSatelliteSetSpec{
+					UpdateStrategy: &piraeusv1.SatelliteUpdateStrategy{Type: tcase.strategy},
+					KernelModuleInjectionImages: []*shared.KernelModuleInjectionImage{
+						{Name: "all", Image: "drbd9"},
+					},
+					NodeOverrides: []*shared.SatelliteNodeOverride{
+						{Name: "all"},
+					},
+				},
+			}
+
+			pod := &corev1.Pod{
+				ObjectMeta: getObjectMeta(satelliteSet, "%s-node-abcde"),
+				Spec:       corev1.PodSpec{NodeName: "node-with-satellite"},
+			}
+
+			kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+				pod,
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-with-satellite"}},
+				&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "new-node"}},
+			).Build()
+
+			r := &ReconcileLinstorSatelliteSet{client: kubeClient, scheme: scheme.Scheme}
+
+			_, err := r.reconcileNodeGroups(context.Background(), satelliteSet)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name, group := range tcase.expected {
+				k8sNode := &corev1.Node{}
+
+				err := kubeClient.Get(context.Background(), client.ObjectKey{Name: name}, k8sNode)
+				if err != nil {
+					t.Fatalf("failed to get node: %v", err)
+				}
+
+				if k8sNode.Labels[kubeSpec.KernelModuleInjectionGroupLabel] != group {
+					t.Errorf("node %s: expected group %q, got %q", name, group, k8sNode.Labels[kubeSpec.KernelModuleInjectionGroupLabel])
+				}
+
+				if k8sNode.Labels[kubeSpec.SatelliteNodeOverrideLabel] != group {
+					t.Errorf("node %s: expected override %q, got %q", name, group, k8sNode.Labels[kubeSpec.SatelliteNodeOverrideLabel])
+				}
+			}
+		})
+	}
+}
+
+func TestRemoveDanglingSatellites(t *testing.T) {
+	missingSince := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	satellite := func(name, status string, flags ...string) lapi.Node {
+		return lapi.Node{
+			Name:             name,
+			Type:             lc.Satellite,
+			ConnectionStatus: status,
+			Flags:            flags,
+			Props:            map[string]string{kubeSpec.LinstorSatelliteSetProperty: "piraeus/piraeus-ns"},
+		}
+	}
+
+	otherSet := satellite("node-1", lc.Offline)
+	otherSet.Props[kubeSpec.LinstorSatelliteSetProperty] = "piraeus/other"
+
+	testcases := []struct {
+		name             string
+		policy           shared.DanglingSatellitePolicy
+		node             lapi.Node
+		k8sNodes         []corev1.Node
+		missingSince     *metav1.Time
+		expectedPhase    string
+		expectedError    bool
+		expectedRequests []string
+	}{
+		{
+			name:   "k8s-node-exists",
+			policy: shared.DanglingSatelliteEvictAndLost,
+			node:   satellite("node-1", lc.Offline),
+			k8sNodes: []corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+			},
+		},
+		{
+			name:          "within-grace-period",
+			policy:        shared.DanglingSatelliteEvictAndLost,
+			node:          satellite("node-1", lc.Offline),
+			expectedPhase: piraeusv1.DanglingSatellitePending,
+		},
+		{
+			name:          "report-only",
+			policy:        shared.DanglingSatelliteReportOnly,
+			node:          satellite("node-1", lc.Offline),
+			missingSince:  &missingSince,
+			expectedPhase: piraeusv1.DanglingSatelliteReported,
+		},
+		{
+			name:             "evict",
+			policy:           shared.DanglingSatelliteEvict,
+			node:             satellite("node-1", lc.Offline, linstor.FlagEvicted),
+			missingSince:     &missingSince,
+			expectedPhase:    piraeusv1.DanglingSatelliteEvicted,
+			expectedRequests: []string{"PUT /v1/nodes/node-1/evict"},
+		},
+		{
+			name:             "evict-and-lost-waiting-for-eviction",
+			policy:           shared.DanglingSatelliteEvictAndLost,
+			node:             satellite("node-1", lc.Offline),
+			missingSince:     &missingSince,
+			expectedPhase:    piraeusv1.DanglingSatelliteEvicted,
+			expectedRequests: []string{"PUT /v1/nodes/node-1/evict"},
+		},
+		{
+			name:             "evict-and-lost",
+			policy:           shared.DanglingSatelliteEvictAndLost,
+			node:             satellite("node-1", lc.Offline, linstor.FlagEvicted),
+			missingSince:     &missingSince,
+			expectedRequests: []string{"PUT /v1/nodes/node-1/evict", "DELETE /v1/nodes/node-1/lost"},
+		},
+		{
+			name:          "online-without-k8s-node",
+			policy:        shared.DanglingSatelliteEvictAndLost,
+			node:          satellite("node-1", lc.Online),
+			missingSince:  &missingSince,
+			expectedPhase: piraeusv1.DanglingSatelliteOnline,
+			expectedError: true,
+		},
+		{
+			name:         "owned-by-other-set",
+			policy:       shared.DanglingSatelliteEvictAndLost,
+			node:         otherSet,
+			missingSince: &missingSince,
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			var requests []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/nodes":
+					_ = json.NewEncoder(w).Encode([]lapi.Node{tcase.node})
+				default:
+					requests = append(requests, r.Method+" "+r.URL.Path)
+					_, _ = w.Write([]byte("[]"))
+				}
+			}))
+			defer server.Close()
+
+			linstorClient, err := lc.NewHighLevelLinstorClientFromConfig(server.URL, &shared.LinstorClientConfig{}, nil)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			satelliteSet := &piraeusv1.LinstorSatelliteSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns", Namespace: "piraeus"},
+				Spec: piraeusv1.LinstorSatelliteSetSpec{
+					DanglingSatellitePolicy:      tcase.policy,
+					DanglingSatelliteGracePeriod: &metav1.Duration{Duration: 10 * time.Minute},
+				},
+			}
+
+			if tcase.missingSince != nil {
+				satelliteSet.Status.DanglingSatellites = []*piraeusv1.DanglingSatelliteStatus{
+					{NodeName: tcase.node.Name, Since: *tcase.missingSince, Phase: piraeusv1.DanglingSatellitePending},
+				}
+			}
+
+			r := &ReconcileLinstorSatelliteSet{recorder: record.NewFakeRecorder(10)}
+
+			err = r.removeDanglingSatellites(context.Background(), linstorClient, satelliteSet, tcase.k8sNodes)
+			if tcase.expectedError != (err != nil) {
+				t.Errorf("expected error: %t, got: %v", tcase.expectedError, err)
+			}
+
+			var phases []string
+			for _, dangling := range satelliteSet.Status.DanglingSatellites {
+				phases = append(phases, dangling.Phase)
+			}
+
+			var expectedPhases []string
+			if tcase.expectedPhase != "" {
+				expectedPhases = []string{tcase.expectedPhase}
+			}
+
+			if !reflect.DeepEqual(expectedPhases, phases) {
+				t.Errorf("expected phases %v, got %v", expectedPhases, phases)
+			}
+
+			if !reflect.DeepEqual(tcase.expectedRequests, requests) {
+				t.Errorf("expected requests %v, got %v", tcase.expectedRequests, requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	monitoringv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+// With a monitoring proxy, drbd-reactor only listens on the loopback interface. A kube-rbac-proxy sidecar takes over
+// the monitoring port, terminates TLS and checks that the bearer token of the client is allowed to read the metrics.
+
+// monitoringUpstreamPort is the port drbd-reactor listens on if the monitoring proxy is enabled.
+const monitoringUpstreamPort = 9943
+
+// serviceAccountTokenFile is the path of the token Prometheus uses to authenticate against the monitoring proxy.
+const serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// monitoringListenHost returns the address the metrics endpoint listens on.
+func monitoringListenHost(set *piraeusv1.LinstorSatelliteSet) string {
+	listenHost := "0.0.0.0"
+
+	for _, f := range set.Spec.IPFamilies {
+		if f == shared.IPv6 {
+			// Also accepts IPv4 connections, unless disabled on the host
+			listenHost = "::"
+		}
+	}
+
+	return listenHost
+}
+
+// monitoringLoopbackHost returns the loopback address drbd-reactor listens on if the monitoring proxy is enabled.
+func monitoringLoopbackHost(set *piraeusv1.LinstorSatelliteSet) string {
+	if monitoringListenHost(set) == "::" {
+		return "::1"
+	}
+
+	return "127.0.0.1"
+}
+
+// prometheusListenAddress returns the address of the drbd-reactor prometheus plugin.
+func prometheusListenAddress(set *piraeusv1.LinstorSatelliteSet) string {
+	if set.Spec.MonitoringProxy != nil {
+		return net.JoinHostPort(monitoringLoopbackHost(set), strconv.Itoa(monitoringUpstreamPort))
+	}
+
+	return net.JoinHostPort(monitoringListenHost(set), strconv.Itoa(monitoringPort))
+}
+
+// validateMonitoringProxy checks that the monitoring proxy can be deployed.
+func validateMonitoringProxy(set *piraeusv1.LinstorSatelliteSet) error {
+	proxy := set.Spec.MonitoringProxy
+	if proxy == nil {
+		return nil
+	}
+
+	if set.Spec.MonitoringImage == "" {
+		return fmt.Errorf("monitoringProxy requires monitoringImage to be set")
+	}
+
+	if proxy.Image == "" {
+		return fmt.Errorf("monitoringProxy.image is required")
+	}
+
+	if proxy.TLSSecret == "" {
+		return fmt.Errorf("monitoringProxy.tlsSecret is required")
+	}
+
+	return nil
+}
+
+// newMonitoringProxyContainer returns the kube-rbac-proxy container serving the metrics of drbd-reactor.
+func newMonitoringProxyContainer(set *piraeusv1.LinstorSatelliteSet) corev1.Container {
+	return corev1.Container{
+		Name:            "monitoring-proxy",
+		Image:           set.Spec.MonitoringProxy.Image,
+		ImagePullPolicy: set.Spec.ImagePullPolicy,
+		Args: []string{
+			"--secure-listen-address=" + net.JoinHostPort(monitoringListenHost(set), strconv.Itoa(monitoringPort)),
+			"--upstream=http://" + net.JoinHostPort(monitoringLoopbackHost(set), strconv.Itoa(monitoringUpstreamPort)) + "/",
+			"--tls-cert-file=" + kubeSpec.MonitoringProxyTLSDir + corev1.TLSCertKey,
+			"--tls-private-key-file=" + kubeSpec.MonitoringProxyTLSDir + corev1.TLSPrivateKeyKey,
+			"--logtostderr=true",
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          kubeSpec.MonitoringPortName,
+				ContainerPort: monitoringPort,
+				HostPort:      monitoringPort,
+				Protocol:      corev1.ProtocolTCP,
+			},
+		},
+		// Requests without a valid token are rejected, so only check that the proxy accepts connections.
+		LivenessProbe: &corev1.Probe{
+			Handler: corev1.Handler{
+				TCPSocket: &corev1.TCPSocketAction{
+					Port: intstr.FromInt(monitoringPort),
+				},
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      kubeSpec.MonitoringProxyTLSDirName,
+				MountPath: kubeSpec.MonitoringProxyTLSDir,
+				ReadOnly:  true,
+			},
+		},
+	}
+}
+
+// newMonitoringProxyVolume returns the volume holding the certificate of the monitoring proxy.
+func newMonitoringProxyVolume(set *piraeusv1.LinstorSatelliteSet) corev1.Volume {
+	return corev1.Volume{
+		Name: kubeSpec.MonitoringProxyTLSDirName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: set.Spec.MonitoringProxy.TLSSecret,
+			},
+		},
+	}
+}
+
+// serviceMonitorWithProxy configures the service monitor to scrape the metrics via the monitoring proxy, using TLS
+// and the service account token of Prometheus.
+func serviceMonitorWithProxy(serviceMonitor *monitoringv1.ServiceMonitor, set *piraeusv1.LinstorSatelliteSet, service *corev1.Service) {
+	if set.Spec.MonitoringProxy == nil {
+		return
+	}
+
+	for i := range serviceMonitor.Spec.Endpoints {
+		endpoint := &serviceMonitor.Spec.Endpoints[i]
+		endpoint.Scheme = string(corev1.URISchemeHTTPS)
+		endpoint.BearerTokenFile = serviceAccountTokenFile
+		endpoint.TLSConfig = &monitoringv1.TLSConfig{
+			CA: monitoringv1.SecretOrConfigMap{
+				Secret: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: set.Spec.MonitoringProxy.TLSSecret},
+					Key:                  "ca.crt",
+				},
+			},
+			// Targets are scraped by node address, so the name has to be set explicitly.
+			ServerName: fmt.Sprintf("%s.%s.svc", service.Name, service.Namespace),
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package linstorsatelliteset
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	"github.com/piraeusdatastore/piraeus-operator/pkg/k8s/monitoring"
+)
+
+func TestPrometheusListenAddress(t *testing.T) {
+	testcases := []struct {
+		name     string
+		spec     piraeusv1.LinstorSatelliteSetSpec
+		expected string
+	}{
+		{name: "default", expected: "0.0.0.0:9942"},
+		{name: "ipv6", spec: piraeusv1.LinstorSatelliteSetSpec{IPFamilies: []shared.IPFamily{shared.IPv6}}, expected: "[::]:9942"},
+		{name: "proxy", spec: piraeusv1.LinstorSatelliteSetSpec{MonitoringProxy: &piraeusv1.MonitoringProxySpec{}}, expected: "127.0.0.1:9943"},
+		{name: "proxy-ipv6", spec: piraeusv1.LinstorSatelliteSetSpec{IPFamilies: []shared.IPFamily{shared.IPv6}, MonitoringProxy: &piraeusv1.MonitoringProxySpec{}}, expected: "[::1]:9943"},
+	}
+
+	for _, tcase := range testcases {
+		tcase := tcase
+
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := prometheusListenAddress(&piraeusv1.LinstorSatelliteSet{Spec: tcase.spec})
+			if actual != tcase.expected {
+				t.Errorf("expected '%s', got '%s'", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestValidateMonitoringProxy(t *testing.T) {
+	testcases := []struct {
+		name      string
+		spec      piraeusv1.LinstorSatelliteSetSpec
+		expectErr bool
+	}{
+		{name: "none"},
+		{
+			name:      "no-monitoring",
+			spec:      piraeusv1.LinstorSatelliteSetSpec{MonitoringProxy: &piraeusv1.MonitoringProxySpec{Image: "proxy", TLSSecret: "tls"}},
+			expectErr: true,
+		},
+		{
+			name:      "no-image",
+			spec:      piraeusv1.LinstorSatelliteSetSpec{MonitoringImage: "monitoring", MonitoringProxy: &piraeusv1.MonitoringProxySpec{TLSSecret: "tls"}},
+			expectErr: true,
+		},
+		{
+			name:      "no-secret",
+			spec:      piraeusv1.LinstorSatelliteSetSpec{MonitoringImage: "monitoring", MonitoringProxy: &piraeusv1.MonitoringProxySpec{Image: "proxy"}},
+			expectErr: true,
+		},
+		{
+			name: "valid",
+			spec: piraeusv1.LinstorSatelliteSetSpec{MonitoringImage: "monitoring", MonitoringProxy: &piraeusv1.MonitoringProxySpec{Image: "proxy", TLSSecret: "tls"}},
+		},
+	}
+
+	for _, tcase := range testcases {
+		tcase := tcase
+
+		t.Run(tcase.name, func(t *testing.T) {
+			err := validateMonitoringProxy(&piraeusv1.LinstorSatelliteSet{Spec: tcase.spec})
+			if tcase.expectErr && err == nil {
+				t.Errorf("expected error")
+			}
+
+			if !tcase.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestServiceMonitorWithProxy(t *testing.T) {
+	set := &piraeusv1.LinstorSatelliteSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns", Namespace: "piraeus"},
+		Spec:       piraeusv1.LinstorSatelliteSetSpec{MonitoringImage: "monitoring"},
+	}
+
+	service := newMonitoringService(set)
+
+	plain := monitoring.MonitorForService(service)
+	serviceMonitorWithProxy(plain, set, service)
+
+	if plain.Spec.Endpoints[0].Scheme != string(corev1.URISchemeHTTP) || plain.Spec.Endpoints[0].TLSConfig != nil {
+		t.Errorf("expected plain endpoint without proxy, got %+v", plain.Spec.Endpoints[0])
+	}
+
+	set.Spec.MonitoringProxy = &piraeusv1.MonitoringProxySpec{Image: "proxy", TLSSecret: "monitoring-tls"}
+
+	secured := monitoring.MonitorForService(service)
+	serviceMonitorWithProxy(secured, set, service)
+
+	endpoint := secured.Spec.Endpoints[0]
+	if endpoint.Scheme != string(corev1.URISchemeHTTPS) {
+		t.Errorf("expected https scheme, got '%s'", endpoint.Scheme)
+	}
+
+	if endpoint.BearerTokenFile == "" {
+		t.Errorf("expected bearer token to be configured")
+	}
+
+	if endpoint.TLSConfig == nil || endpoint.TLSConfig.CA.Secret == nil || endpoint.TLSConfig.CA.Secret.Name != "monitoring-tls" {
+		t.Fatalf("expected CA from TLS secret, got %+v", endpoint.TLSConfig)
+	}
+
+	if endpoint.TLSConfig.ServerName != "piraeus-ns-monitoring.piraeus.svc" {
+		t.Errorf("unexpected server name '%s'", endpoint.TLSConfig.ServerName)
+	}
+}
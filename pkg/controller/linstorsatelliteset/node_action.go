@@ -0,0 +1,193 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	"github.com/piraeusdatastore/piraeus-operator/pkg/k8s/reconcileutil"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+// Node actions are one-shot commands executed on a specific node, for storage setup LINSTOR can't do on its own.
+// They run as Jobs using the satellite image, so the same tools the satellite uses are available.
+
+// maxJobNameLength ensures the "job-name" label added to the pods stays a valid label value.
+const maxJobNameLength = 63
+
+// runNodeAction ensures the command runs to completion on the given node.
+//
+// Returns nil once the command completed successfully. The finished Job is removed, so the caller is expected to
+// not request the same action again. While the command is still running, a TemporaryError is returned.
+func (r *ReconcileLinstorSatelliteSet) runNodeAction(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName, action string, command []string) error {
+	logger := log.WithFields(logrus.Fields{
+		"Name":      satelliteSet.Name,
+		"Namespace": satelliteSet.Namespace,
+		"Node":      nodeName,
+		"Action":    action,
+		"Op":        "runNodeAction",
+	})
+
+	desired := newNodeActionJob(satelliteSet, nodeName, action, command)
+
+	job := &batchv1.Job{}
+
+	err := r.client.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, job)
+	if errors.IsNotFound(err) {
+		logger.WithField("command", command).Info("starting node action")
+
+		err := controllerutil.SetControllerReference(satelliteSet, desired, r.scheme)
+		if err != nil {
+			return err
+		}
+
+		err = r.client.Create(ctx, desired)
+		if err != nil {
+			return fmt.Errorf("failed to create job for node action '%s' on node '%s': %w", action, nodeName, err)
+		}
+
+		job = desired
+	} else if err != nil {
+		return fmt.Errorf("failed to fetch job for node action '%s' on node '%s': %w", action, nodeName, err)
+	}
+
+	if job.Status.Failed > 0 {
+		return fmt.Errorf("node action '%s' on node '%s' failed, check the logs of job '%s' and delete it to retry", action, nodeName, job.Name)
+	}
+
+	if job.Status.Succeeded == 0 {
+		return &reconcileutil.TemporaryError{
+			Source:       fmt.Errorf("waiting for node action '%s' on node '%s' to complete", action, nodeName),
+			RequeueAfter: connectionRetrySeconds * time.Second,
+		}
+	}
+
+	logger.Info("node action completed, removing job")
+
+	policy := metav1.DeletePropagationBackground
+
+	err = r.client.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &policy})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to remove completed job '%s': %w", job.Name, err)
+	}
+
+	return nil
+}
+
+func newNodeActionJob(satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName, action string, command []string) *batchv1.Job {
+	var pullSecrets []corev1.LocalObjectReference
+	if satelliteSet.Spec.DrbdRepoCred != "" {
+		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: satelliteSet.Spec.DrbdRepoCred})
+	}
+
+	meta := getObjectMeta(satelliteSet, "%s")
+	meta.Name = nodeActionJobName(satelliteSet.Name, nodeName, action, command)
+	meta.Labels[kubeSpec.NodeActionLabel] = action
+
+	backoffLimit := int32(0)
+
+	return &batchv1.Job{
+		ObjectMeta: meta,
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						kubeSpec.NodeActionLabel: action,
+					},
+				},
+				Spec: corev1.PodSpec{
+					NodeName:           nodeName,
+					RestartPolicy:      corev1.RestartPolicyNever,
+					Tolerations:        satelliteSet.Spec.Tolerations,
+					PriorityClassName:  satelliteSet.Spec.PriorityClassName.GetName(satelliteSet.Namespace),
+					ServiceAccountName: getServiceAccountName(satelliteSet),
+					ImagePullSecrets:   pullSecrets,
+					Containers: []corev1.Container{
+						{
+							Name:            action,
+							Image:           satelliteSet.Spec.SatelliteImage,
+							ImagePullPolicy: satelliteSet.Spec.ImagePullPolicy,
+							Command:         command,
+							SecurityContext: &corev1.SecurityContext{Privileged: &kubeSpec.Privileged},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      kubeSpec.DevDirName,
+									MountPath: kubeSpec.DevDir,
+								},
+								{
+									Name:      kubeSpec.SysDirName,
+									MountPath: kubeSpec.SysDir,
+								},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: kubeSpec.DevDirName,
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: kubeSpec.DevDir,
+								},
+							},
+						},
+						{
+							Name: kubeSpec.SysDirName,
+							VolumeSource: corev1.VolumeSource{
+								HostPath: &corev1.HostPathVolumeSource{
+									Path: kubeSpec.SysDir,
+									Type: &kubeSpec.HostPathDirectoryType,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// nodeActionJobName returns a stable name for the job. A changed command results in a new name, so a failed job
+// does not block an updated action.
+func nodeActionJobName(setName, nodeName, action string, command []string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(nodeName))
+	_, _ = h.Write([]byte(strings.Join(command, "\x00")))
+
+	suffix := fmt.Sprintf("-%s-%08x", action, h.Sum32())
+
+	if len(setName)+len(suffix) > maxJobNameLength {
+		setName = setName[:maxJobNameLength-len(suffix)]
+	}
+
+	return strings.TrimRight(setName, "-.") + suffix
+}
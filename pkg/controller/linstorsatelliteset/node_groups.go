@@ -0,0 +1,357 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+// Nodes are grouped by the kernel module injection image they need and by the node override applying to them.
+// Every node is labelled with the name of its injection group and override, and every combination gets its own
+// satellite DaemonSet, restricted to nodes with the matching labels. Nodes without a group are handled by the default
+// satellite DaemonSet, using KernelModuleInjectionImage and the resources from the spec.
+//
+// Changing the labels of a node moves its satellite to another DaemonSet, replacing the satellite pod. With the
+// "Managed" update strategy, nodes running a satellite are therefore only relabelled as part of the rollout.
+
+// reconcileNodeGroups labels all kubernetes nodes with the kernel module injection group and node override they
+// belong to. With the "Managed" update strategy, nodes running a satellite are skipped, see
+// reconcileSatelliteRollout.
+//
+// Returns the nodes running a satellite, but not matching any kernel module injection image.
+func (r *ReconcileLinstorSatelliteSet) reconcileNodeGroups(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet) ([]string, error) {
+	logger := log.WithFields(logrus.Fields{
+		"Name":      satelliteSet.Name,
+		"Namespace": satelliteSet.Namespace,
+		"Op":        "reconcileNodeGroups",
+	})
+
+	k8sNodes := &corev1.NodeList{}
+
+	err := r.client.List(ctx, k8sNodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes nodes: %w", err)
+	}
+
+	pods, err := r.getAllNodePods(ctx, satelliteSet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list satellite pods: %w", err)
+	}
+
+	satelliteNodes := make(map[string]bool, len(pods))
+	for i := range pods {
+		satelliteNodes[pods[i].Spec.NodeName] = true
+	}
+
+	var unmatched []string
+
+	for i := range k8sNodes.Items {
+		k8sNode := &k8sNodes.Items[i]
+
+		// Nodes outside the node selector are labelled by the satellite set selecting them.
+		if !selectsNode(satelliteSet, k8sNode) {
+			continue
+		}
+
+		wanted := nodeGroupLabels(satelliteSet, k8sNode)
+
+		if wanted[kubeSpec.KernelModuleInjectionGroupLabel] == "" && len(satelliteSet.Spec.KernelModuleInjectionImages) != 0 && satelliteNodes[k8sNode.Name] {
+			unmatched = append(unmatched, k8sNode.Name)
+		}
+
+		if nodeGroupLabelsMatch(k8sNode, wanted) {
+			continue
+		}
+
+		if satelliteSet.Spec.UpdateStrategy.Type == piraeusv1.SatelliteUpdateManaged && satelliteNodes[k8sNode.Name] {
+			logger.WithField("node", k8sNode.Name).Debug("node groups changed, waiting for rollout")
+
+			continue
+		}
+
+		logger.WithFields(logrus.Fields{
+			"node":     k8sNode.Name,
+			"group":    wanted[kubeSpec.KernelModuleInjectionGroupLabel],
+			"override": wanted[kubeSpec.SatelliteNodeOverrideLabel],
+		}).Info("update node groups")
+
+		err := r.setNodeGroupLabels(ctx, k8sNode, wanted)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(unmatched)
+
+	return unmatched, nil
+}
+
+// nodeGroupLabels returns the wanted group labels of the node. Empty values mean the label should be removed.
+func nodeGroupLabels(satelliteSet *piraeusv1.LinstorSatelliteSet, k8sNode *corev1.Node) map[string]string {
+	wanted := map[string]string{
+		kubeSpec.KernelModuleInjectionGroupLabel: "",
+		kubeSpec.SatelliteNodeOverrideLabel:      "",
+	}
+
+	group := shared.SelectKernelModuleInjectionImage(satelliteSet.Spec.KernelModuleInjectionImages, k8sNode)
+	if group != nil {
+		wanted[kubeSpec.KernelModuleInjectionGroupLabel] = group.Name
+	}
+
+	override := shared.SelectSatelliteNodeOverride(satelliteSet.Spec.NodeOverrides, k8sNode)
+	if override != nil {
+		wanted[kubeSpec.SatelliteNodeOverrideLabel] = override.Name
+	}
+
+	return wanted
+}
+
+// nodeGroupLabelsMatch returns true if the node has the wanted group labels. Empty values require the label to be
+// missing.
+func nodeGroupLabelsMatch(k8sNode *corev1.Node, wanted map[string]string) bool {
+	for k, v := range wanted {
+		if k8sNode.Labels[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setNodeGroupLabels sets the group labels on the node. Empty values remove the label.
+func (r *ReconcileLinstorSatelliteSet) setNodeGroupLabels(ctx context.Context, k8sNode *corev1.Node, groups map[string]string) error {
+	values := make(map[string]interface{}, len(groups))
+
+	for k, v := range groups {
+		if v == "" {
+			values[k] = nil
+		} else {
+			values[k] = v
+		}
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"labels": values,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.client.Patch(ctx, k8sNode, client.RawPatch(types.MergePatchType, patch))
+	if err != nil {
+		return fmt.Errorf("failed to label node '%s' with node groups: %w", k8sNode.Name, err)
+	}
+
+	return nil
+}
+
+// removeStaleNodeGroupDaemonSets deletes satellite DaemonSets of kernel module injection groups or node overrides
+// no longer in the spec.
+func (r *ReconcileLinstorSatelliteSet) removeStaleNodeGroupDaemonSets(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet, current []*apps.DaemonSet) error {
+	meta := getObjectMeta(satelliteSet, "%s")
+
+	daemonSets := &apps.DaemonSetList{}
+
+	err := r.client.List(ctx, daemonSets, client.InNamespace(satelliteSet.Namespace), client.MatchingLabels(meta.Labels))
+	if err != nil {
+		return fmt.Errorf("failed to list satellite daemonsets: %w", err)
+	}
+
+	wanted := make(map[string]bool, len(current))
+	for _, ds := range current {
+		wanted[ds.Name] = true
+	}
+
+	for i := range daemonSets.Items {
+		ds := &daemonSets.Items[i]
+
+		if wanted[ds.Name] {
+			continue
+		}
+
+		log.WithField("daemonset", ds.Name).Info("remove satellite daemonset of removed node group")
+
+		err := r.client.Delete(ctx, ds)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to remove satellite daemonset '%s': %w", ds.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// newSatelliteDaemonSets returns the default satellite DaemonSet, and one DaemonSet for every combination of kernel
+// module injection group and node override.
+func newSatelliteDaemonSets(satelliteSet *piraeusv1.LinstorSatelliteSet, satelliteCM, drbdReactorConfig *corev1.ConfigMap) []*apps.DaemonSet {
+	groups := append([]*shared.KernelModuleInjectionImage{nil}, satelliteSet.Spec.KernelModuleInjectionImages...)
+	overrides := append([]*shared.SatelliteNodeOverride{nil}, satelliteSet.Spec.NodeOverrides...)
+
+	result := make([]*apps.DaemonSet, 0, len(groups)*len(overrides))
+
+	for _, group := range groups {
+		for _, override := range overrides {
+			result = append(result, newSatelliteDaemonSet(satelliteSet, group, override, satelliteCM, drbdReactorConfig))
+		}
+	}
+
+	return result
+}
+
+// satelliteDaemonSetName returns the name format of the satellite DaemonSet for the kernel module injection group
+// and node override. Both are optional.
+func satelliteDaemonSetName(group *shared.KernelModuleInjectionImage, override *shared.SatelliteNodeOverride) string {
+	name := "%s-node"
+
+	if group != nil {
+		name += "-" + group.Name
+	}
+
+	if override != nil {
+		name += "-" + override.Name
+	}
+
+	return name
+}
+
+// validateNodeGroupNames checks that every combination of kernel module injection group and node override results
+// in a distinct DaemonSet name.
+func validateNodeGroupNames(satelliteSet *piraeusv1.LinstorSatelliteSet) error {
+	groups := append([]*shared.KernelModuleInjectionImage{nil}, satelliteSet.Spec.KernelModuleInjectionImages...)
+	overrides := append([]*shared.SatelliteNodeOverride{nil}, satelliteSet.Spec.NodeOverrides...)
+
+	names := make(map[string]bool, len(groups)*len(overrides))
+
+	for _, group := range groups {
+		for _, override := range overrides {
+			name := satelliteDaemonSetName(group, override)
+			if names[name] {
+				return fmt.Errorf("kernelModuleInjectionImages and nodeOverrides: satellite daemonset '%s' would be created twice, use distinct names", fmt.Sprintf(name, satelliteSet.Name))
+			}
+
+			names[name] = true
+		}
+	}
+
+	return nil
+}
+
+// daemonSetWithNodeOverride applies the container resources and JVM options of the node override.
+func daemonSetWithNodeOverride(ds *apps.DaemonSet, override *shared.SatelliteNodeOverride) *apps.DaemonSet {
+	if override == nil {
+		return ds
+	}
+
+	podSpec := &ds.Spec.Template.Spec
+
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+
+		switch container.Name {
+		case satelliteContainerName:
+			if override.Resources != nil {
+				container.Resources = *override.Resources
+			}
+
+			if override.JavaOpts != "" {
+				container.Env = envWithJavaOpts(container.Env, override.JavaOpts)
+			}
+		case monitoringContainerName:
+			if override.MonitoringResources != nil {
+				container.Resources = *override.MonitoringResources
+			}
+		}
+	}
+
+	for i := range podSpec.InitContainers {
+		container := &podSpec.InitContainers[i]
+
+		if container.Name == kernelModuleInjectorContainerName && override.KernelModuleInjectionResources != nil {
+			container.Resources = *override.KernelModuleInjectionResources
+		}
+	}
+
+	return ds
+}
+
+// envWithJavaOpts appends the options to the first JAVA_OPTS entry of the environment, or adds a new entry in front.
+// Later JAVA_OPTS entries from AdditionalEnv still take precedence.
+func envWithJavaOpts(env []corev1.EnvVar, javaOpts string) []corev1.EnvVar {
+	result := make([]corev1.EnvVar, 0, len(env)+1)
+	found := false
+
+	for _, e := range env {
+		if e.Name == kubeSpec.JavaOptsName && !found && e.ValueFrom == nil {
+			e.Value = strings.TrimSpace(e.Value + " " + javaOpts)
+			found = true
+		}
+
+		result = append(result, e)
+	}
+
+	if !found {
+		result = append([]corev1.EnvVar{{Name: kubeSpec.JavaOptsName, Value: javaOpts}}, result...)
+	}
+
+	return result
+}
+
+// affinityWithNodeRequirement returns a copy of the affinity, additionally requiring the given node label.
+//
+// Required node selector terms are ORed, so the requirement is added to every existing term.
+func affinityWithNodeRequirement(affinity *corev1.Affinity, requirement corev1.NodeSelectorRequirement) *corev1.Affinity {
+	result := affinity.DeepCopy()
+	if result == nil {
+		result = &corev1.Affinity{}
+	}
+
+	if result.NodeAffinity == nil {
+		result.NodeAffinity = &corev1.NodeAffinity{}
+	}
+
+	if result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+
+	required := result.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	for i := range required.NodeSelectorTerms {
+		required.NodeSelectorTerms[i].MatchExpressions = append(required.NodeSelectorTerms[i].MatchExpressions, requirement)
+	}
+
+	return result
+}
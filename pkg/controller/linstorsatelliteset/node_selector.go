@@ -0,0 +1,152 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	lapi "github.com/LINBIT/golinstor/client"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+// Multiple LinstorSatelliteSets can register satellites on the same controller, as long as their node selectors
+// don't overlap. Every satellite records the set that registered it, so that each set only manages its own
+// satellites. If the selectors of two sets overlap, the newer set is rejected until the conflict is resolved.
+
+// satelliteSetOwner returns the value of the ownership property for satellites registered by the set.
+func satelliteSetOwner(satelliteSet *piraeusv1.LinstorSatelliteSet) string {
+	return types.NamespacedName{Namespace: satelliteSet.Namespace, Name: satelliteSet.Name}.String()
+}
+
+// selectsNode returns true if the node selector of the set matches the kubernetes node.
+func selectsNode(satelliteSet *piraeusv1.LinstorSatelliteSet, k8sNode *corev1.Node) bool {
+	return labels.SelectorFromSet(satelliteSet.Spec.NodeSelector).Matches(labels.Set(k8sNode.Labels))
+}
+
+// ownedBySatelliteSet returns true if the satellite was registered by the set. Satellites registered before sets
+// recorded their ownership belong to every set.
+func ownedBySatelliteSet(node *lapi.Node, satelliteSet *piraeusv1.LinstorSatelliteSet) bool {
+	owner, ok := node.Props[kubeSpec.LinstorSatelliteSetProperty]
+
+	return !ok || owner == satelliteSetOwner(satelliteSet)
+}
+
+// nodeSelectorsOverlap returns true if a node could match both selectors, i.e. no label is required to have
+// different values.
+func nodeSelectorsOverlap(a, b map[string]string) bool {
+	for k, v := range a {
+		if other, ok := b[k]; ok && other != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// createdBefore returns true if the set a was created before b. Sets created at the same time are ordered by name.
+func createdBefore(a, b *piraeusv1.LinstorSatelliteSet) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+
+	return satelliteSetOwner(a) < satelliteSetOwner(b)
+}
+
+// checkNodeSelectorOverlap returns an error if the node selector overlaps with the selector of an older set
+// registering satellites on the same controller.
+func (r *ReconcileLinstorSatelliteSet) checkNodeSelectorOverlap(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet) error {
+	sets := &piraeusv1.LinstorSatelliteSetList{}
+
+	err := r.client.List(ctx, sets)
+	if err != nil {
+		return fmt.Errorf("failed to list satellite sets: %w", err)
+	}
+
+	var conflicts []string
+
+	for i := range sets.Items {
+		other := &sets.Items[i]
+
+		if other.UID == satelliteSet.UID || other.GetDeletionTimestamp() != nil {
+			continue
+		}
+
+		if other.Spec.ControllerEndpoint != satelliteSet.Spec.ControllerEndpoint {
+			continue
+		}
+
+		if nodeSelectorsOverlap(satelliteSet.Spec.NodeSelector, other.Spec.NodeSelector) && createdBefore(other, satelliteSet) {
+			conflicts = append(conflicts, satelliteSetOwner(other))
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	sort.Strings(conflicts)
+
+	r.recorder.Eventf(satelliteSet, corev1.EventTypeWarning, "NodeSelectorOverlap", "Node selector overlaps with satellite sets %v, not deploying satellites", conflicts)
+
+	return fmt.Errorf("node selector overlaps with satellite sets %v using the same controller, set a distinct nodeSelector", conflicts)
+}
+
+// checkSatelliteOwner returns an error if the satellite on the node was registered by a different set that still
+// exists. Satellites of deleted sets are taken over.
+func (r *ReconcileLinstorSatelliteSet) checkSatelliteOwner(ctx context.Context, linstorClient *lc.HighLevelClient, satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName string) error {
+	node, err := linstorClient.Nodes.Get(ctx, nodeName)
+	if err == lapi.NotFoundError {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to get node %s: %w", nodeName, err)
+	}
+
+	if ownedBySatelliteSet(&node, satelliteSet) {
+		return nil
+	}
+
+	owner := node.Props[kubeSpec.LinstorSatelliteSetProperty]
+
+	parts := strings.SplitN(owner, string(types.Separator), 2)
+	if len(parts) == 2 {
+		err := r.client.Get(ctx, types.NamespacedName{Namespace: parts[0], Name: parts[1]}, &piraeusv1.LinstorSatelliteSet{})
+		if errors.IsNotFound(err) {
+			log.WithField("node", nodeName).Infof("satellite set '%s' no longer exists, taking over satellite", owner)
+
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to get satellite set '%s': %w", owner, err)
+		}
+	}
+
+	return fmt.Errorf("satellite '%s' is registered by satellite set '%s'", nodeName, owner)
+}
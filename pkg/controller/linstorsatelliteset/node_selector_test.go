@@ -0,0 +1,104 @@
+package linstorsatelliteset
+
+import (
+	"testing"
+	"time"
+
+	lapi "github.com/LINBIT/golinstor/client"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+func TestNodeSelectorsOverlap(t *testing.T) {
+	testcases := []struct {
+		name     string
+		a, b     map[string]string
+		expected bool
+	}{
+		{name: "both-empty", expected: true},
+		{name: "one-empty", a: map[string]string{"disk": "ssd"}, expected: true},
+		{name: "same", a: map[string]string{"disk": "ssd"}, b: map[string]string{"disk": "ssd"}, expected: true},
+		{name: "different-keys", a: map[string]string{"disk": "ssd"}, b: map[string]string{"zone": "a"}, expected: true},
+		{name: "different-values", a: map[string]string{"disk": "ssd"}, b: map[string]string{"disk": "hdd"}, expected: false},
+		{name: "partially-different", a: map[string]string{"disk": "ssd", "zone": "a"}, b: map[string]string{"disk": "hdd", "zone": "a"}, expected: false},
+	}
+
+	for _, tcase := range testcases {
+		tcase := tcase
+
+		t.Run(tcase.name, func(t *testing.T) {
+			if actual := nodeSelectorsOverlap(tcase.a, tcase.b); actual != tcase.expected {
+				t.Errorf("expected %t, got %t", tcase.expected, actual)
+			}
+
+			if actual := nodeSelectorsOverlap(tcase.b, tcase.a); actual != tcase.expected {
+				t.Errorf("expected %t for swapped selectors, got %t", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOwnedBySatelliteSet(t *testing.T) {
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{ObjectMeta: metav1.ObjectMeta{Name: "ssd", Namespace: "piraeus"}}
+
+	testcases := []struct {
+		name     string
+		props    map[string]string
+		expected bool
+	}{
+		{name: "legacy", props: map[string]string{kubeSpec.LinstorRegistrationProperty: kubeSpec.Name}, expected: true},
+		{name: "owned", props: map[string]string{kubeSpec.LinstorSatelliteSetProperty: "piraeus/ssd"}, expected: true},
+		{name: "other-set", props: map[string]string{kubeSpec.LinstorSatelliteSetProperty: "piraeus/hdd"}, expected: false},
+		{name: "other-namespace", props: map[string]string{kubeSpec.LinstorSatelliteSetProperty: "other/ssd"}, expected: false},
+	}
+
+	for _, tcase := range testcases {
+		tcase := tcase
+
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := ownedBySatelliteSet(&lapi.Node{Name: "node-1", Props: tcase.props}, satelliteSet)
+			if actual != tcase.expected {
+				t.Errorf("expected %t, got %t", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSelectsNode(t *testing.T) {
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"disk": "ssd"}}}
+
+	all := &piraeusv1.LinstorSatelliteSet{}
+	if !selectsNode(all, node) {
+		t.Errorf("expected empty selector to select node")
+	}
+
+	ssd := &piraeusv1.LinstorSatelliteSet{Spec: piraeusv1.LinstorSatelliteSetSpec{NodeSelector: map[string]string{"disk": "ssd"}}}
+	if !selectsNode(ssd, node) {
+		t.Errorf("expected matching selector to select node")
+	}
+
+	hdd := &piraeusv1.LinstorSatelliteSet{Spec: piraeusv1.LinstorSatelliteSetSpec{NodeSelector: map[string]string{"disk": "hdd"}}}
+	if selectsNode(hdd, node) {
+		t.Errorf("expected other selector not to select node")
+	}
+}
+
+func TestCreatedBefore(t *testing.T) {
+	now := metav1.Now()
+	later := metav1.NewTime(now.Add(time.Minute))
+
+	a := &piraeusv1.LinstorSatelliteSet{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "piraeus", CreationTimestamp: now}}
+	b := &piraeusv1.LinstorSatelliteSet{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "piraeus", CreationTimestamp: now}}
+	c := &piraeusv1.LinstorSatelliteSet{ObjectMeta: metav1.ObjectMeta{Name: "0", Namespace: "piraeus", CreationTimestamp: later}}
+
+	if !createdBefore(a, b) || createdBefore(b, a) {
+		t.Errorf("expected sets created at the same time to be ordered by name")
+	}
+
+	if !createdBefore(b, c) || createdBefore(c, a) {
+		t.Errorf("expected sets to be ordered by creation time")
+	}
+}
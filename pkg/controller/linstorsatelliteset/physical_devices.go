@@ -0,0 +1,333 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+// LINSTOR only reports devices that are empty, i.e. can be used for a new storage pool. Once a device is used by a
+// storage pool from the spec, the LinstorPhysicalDevice is kept, using the last known device properties. Devices
+// that are neither empty nor claimed by a storage pool are removed from the inventory.
+
+// invalidNameChars matches all characters not allowed in resource names.
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// reconcilePhysicalDevices updates the LinstorPhysicalDevice resources of a node from the physical storage reported
+// by LINSTOR.
+func (r *ReconcileLinstorSatelliteSet) reconcilePhysicalDevices(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName string, storageList []lapi.PhysicalStorage) error {
+	logger := log.WithFields(logrus.Fields{
+		"Name":      satelliteSet.Name,
+		"Namespace": satelliteSet.Namespace,
+		"Node":      nodeName,
+		"Op":        "reconcilePhysicalDevices",
+	})
+
+	existing := &piraeusv1.LinstorPhysicalDeviceList{}
+
+	err := r.client.List(ctx, existing, client.InNamespace(satelliteSet.Namespace), client.MatchingLabels(physicalDeviceLabels(satelliteSet, nodeName)))
+	if err != nil {
+		return fmt.Errorf("failed to list physical devices: %w", err)
+	}
+
+	desired := physicalDevicesForNode(satelliteSet, nodeName, storageList, existing.Items)
+
+	current := make(map[string]*piraeusv1.LinstorPhysicalDevice, len(existing.Items))
+	for i := range existing.Items {
+		current[existing.Items[i].Name] = &existing.Items[i]
+	}
+
+	for _, device := range desired {
+		err := r.applyPhysicalDevice(ctx, satelliteSet, current[device.Name], device)
+		if err != nil {
+			return err
+		}
+
+		delete(current, device.Name)
+	}
+
+	for _, device := range current {
+		logger.WithField("device", device.Spec.DevicePath).Info("remove physical device no longer available")
+
+		err := r.client.Delete(ctx, device)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to remove physical device '%s': %w", device.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// applyPhysicalDevice creates or updates the physical device resource, including its status.
+func (r *ReconcileLinstorSatelliteSet) applyPhysicalDevice(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet, current, desired *piraeusv1.LinstorPhysicalDevice) error {
+	if current == nil {
+		current = desired.DeepCopy()
+
+		err := controllerutil.SetControllerReference(satelliteSet, current, r.scheme)
+		if err != nil {
+			return err
+		}
+
+		err = r.client.Create(ctx, current)
+		if err != nil {
+			return fmt.Errorf("failed to create physical device '%s': %w", desired.Name, err)
+		}
+	} else if current.Spec != desired.Spec {
+		current.Spec = desired.Spec
+
+		err := r.client.Update(ctx, current)
+		if err != nil {
+			return fmt.Errorf("failed to update physical device '%s': %w", desired.Name, err)
+		}
+	}
+
+	// Semantic comparison, as quantities read from the API may use a different internal representation.
+	if equality.Semantic.DeepEqual(current.Status, desired.Status) {
+		return nil
+	}
+
+	current.Status = desired.Status
+
+	err := r.client.Status().Update(ctx, current)
+	if err != nil {
+		return fmt.Errorf("failed to update status of physical device '%s': %w", desired.Name, err)
+	}
+
+	return nil
+}
+
+// removeStalePhysicalDevices removes the physical devices of all nodes no longer running a satellite.
+func (r *ReconcileLinstorSatelliteSet) removeStalePhysicalDevices(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet, nodeNames map[string]bool) error {
+	existing := &piraeusv1.LinstorPhysicalDeviceList{}
+
+	err := r.client.List(ctx, existing, client.InNamespace(satelliteSet.Namespace), client.MatchingLabels(getObjectMeta(satelliteSet, "%s").Labels))
+	if err != nil {
+		return fmt.Errorf("failed to list physical devices: %w", err)
+	}
+
+	for i := range existing.Items {
+		device := &existing.Items[i]
+
+		if nodeNames[device.Spec.NodeName] {
+			continue
+		}
+
+		log.WithField("device", device.Name).Info("remove physical device of node without satellite")
+
+		err := r.client.Delete(ctx, device)
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to remove physical device '%s': %w", device.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// physicalDevicesForNode returns the physical devices that should be reported for a node.
+//
+// All devices reported by LINSTOR are available. Previously reported devices that are no longer empty are kept as
+// unavailable if a storage pool claims them.
+func physicalDevicesForNode(satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName string, storageList []lapi.PhysicalStorage, existing []piraeusv1.LinstorPhysicalDevice) []*piraeusv1.LinstorPhysicalDevice {
+	claims := devicePoolClaims(satelliteSet)
+
+	var result []*piraeusv1.LinstorPhysicalDevice
+
+	seen := make(map[string]bool)
+
+	for _, info := range nodeDevices(storageList, nodeName) {
+		info := info
+
+		device := newPhysicalDevice(satelliteSet, nodeName, info.Path)
+		device.Status = piraeusv1.LinstorPhysicalDeviceStatus{
+			Size:       resource.NewQuantity(info.Size, resource.BinarySI),
+			Rotational: info.Rotational,
+			Model:      info.Model,
+			Serial:     info.Serial,
+			WWN:        info.WWN,
+			ClaimedBy:  claims(&info),
+			Available:  true,
+		}
+
+		seen[info.Path] = true
+		result = append(result, device)
+	}
+
+	for i := range existing {
+		info := deviceInfoFromStatus(&existing[i])
+		if seen[info.Path] || claims(info) == "" {
+			continue
+		}
+
+		device := newPhysicalDevice(satelliteSet, nodeName, info.Path)
+		device.Status = *existing[i].Status.DeepCopy()
+		device.Status.ClaimedBy = claims(info)
+		device.Status.Available = false
+
+		result = append(result, device)
+	}
+
+	return result
+}
+
+// devicePoolClaims returns a function that returns the name of the storage pool using a device. Without explicit
+// storage pool, the name of the pool created by the automatic storage setup is used, if enabled and the device is
+// selected.
+func devicePoolClaims(satelliteSet *piraeusv1.LinstorSatelliteSet) func(device *shared.DeviceInfo) string {
+	claims := make(map[string]string)
+
+	if satelliteSet.Spec.StoragePools != nil {
+		for _, pool := range satelliteSet.Spec.StoragePools.AllPhysicalStorageCreators() {
+			for _, devicePath := range pool.GetDevicePaths() {
+				claims[devicePath] = pool.GetName()
+			}
+		}
+	}
+
+	return func(device *shared.DeviceInfo) string {
+		if name, ok := claims[device.Path]; ok {
+			return name
+		}
+
+		if satelliteSet.Spec.AutomaticStorageType == "" || satelliteSet.Spec.AutomaticStorageType == automaticStorageTypeNone {
+			return ""
+		}
+
+		if !satelliteSet.Spec.AutomaticStorageDevices.Selects(device) {
+			return ""
+		}
+
+		return satelliteSet.Spec.AutomaticStorageDevices.PoolName(device)
+	}
+}
+
+// automaticStoragePools groups the devices selected for the automatic storage setup by the name of their storage pool.
+func automaticStoragePools(selection *shared.AutomaticStorageDevices, devices []shared.DeviceInfo) map[string][]string {
+	pools := make(map[string][]string)
+
+	for i := range devices {
+		device := &devices[i]
+
+		if !selection.Selects(device) {
+			continue
+		}
+
+		name := selection.PoolName(device)
+		pools[name] = append(pools[name], device.Path)
+	}
+
+	for _, paths := range pools {
+		sort.Strings(paths)
+	}
+
+	return pools
+}
+
+// operatorCreatedPool returns the storage pool with the given name, or nil if it does not exist or was not registered
+// by the operator.
+func operatorCreatedPool(pools []lapi.StoragePool, name string) *lapi.StoragePool {
+	for i := range pools {
+		if pools[i].StoragePoolName == name && pools[i].Props[kubeSpec.LinstorRegistrationProperty] == kubeSpec.Name {
+			return &pools[i]
+		}
+	}
+
+	return nil
+}
+
+// nodeDevices returns the devices reported by LINSTOR for the node.
+func nodeDevices(storageList []lapi.PhysicalStorage, nodeName string) []shared.DeviceInfo {
+	var result []shared.DeviceInfo
+
+	for _, entry := range storageList {
+		for _, dev := range entry.Nodes[nodeName] {
+			result = append(result, shared.DeviceInfo{
+				Path:       dev.Device,
+				Size:       entry.Size,
+				Rotational: entry.Rotational,
+				Model:      dev.Model,
+				Serial:     dev.Serial,
+				WWN:        dev.Wwn,
+			})
+		}
+	}
+
+	return result
+}
+
+// deviceInfoFromStatus returns the last known properties of a physical device.
+func deviceInfoFromStatus(device *piraeusv1.LinstorPhysicalDevice) *shared.DeviceInfo {
+	info := &shared.DeviceInfo{
+		Path:       device.Spec.DevicePath,
+		Rotational: device.Status.Rotational,
+		Model:      device.Status.Model,
+		Serial:     device.Status.Serial,
+		WWN:        device.Status.WWN,
+	}
+
+	if device.Status.Size != nil {
+		info.Size = device.Status.Size.Value()
+	}
+
+	return info
+}
+
+func newPhysicalDevice(satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName, devicePath string) *piraeusv1.LinstorPhysicalDevice {
+	return &piraeusv1.LinstorPhysicalDevice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      physicalDeviceName(nodeName, devicePath),
+			Namespace: satelliteSet.Namespace,
+			Labels:    physicalDeviceLabels(satelliteSet, nodeName),
+		},
+		Spec: piraeusv1.LinstorPhysicalDeviceSpec{
+			NodeName:   nodeName,
+			DevicePath: devicePath,
+		},
+	}
+}
+
+func physicalDeviceLabels(satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName string) map[string]string {
+	labels := getObjectMeta(satelliteSet, "%s").Labels
+	labels[kubeSpec.PhysicalDeviceNodeLabel] = nodeName
+
+	return labels
+}
+
+// physicalDeviceName returns a valid resource name for the device on the node, for example "node-1-sdb" for "/dev/sdb".
+func physicalDeviceName(nodeName, devicePath string) string {
+	name := strings.ToLower(nodeName + "-" + strings.TrimPrefix(devicePath, "/dev/"))
+	name = invalidNameChars.ReplaceAllString(name, "-")
+
+	return strings.Trim(name, ".-")
+}
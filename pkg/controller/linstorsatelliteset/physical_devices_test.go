@@ -0,0 +1,197 @@
+package linstorsatelliteset
+
+import (
+	"reflect"
+	"testing"
+
+	lapi "github.com/LINBIT/golinstor/client"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+func TestPhysicalDevicesForNode(t *testing.T) {
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{
+		Spec: piraeusv1.LinstorSatelliteSetSpec{
+			StoragePools: &shared.StoragePools{
+				LVMPools: []*shared.StoragePoolLVM{
+					{CommonStoragePoolOptions: shared.CommonStoragePoolOptions{Name: "lvm"}, CommonPhysicalStorageOptions: shared.CommonPhysicalStorageOptions{DevicePaths: []string{"/dev/sdb"}}},
+				},
+			},
+		},
+	}
+
+	storageList := []lapi.PhysicalStorage{
+		{
+			Size:       10 * 1024 * 1024 * 1024,
+			Rotational: true,
+			Nodes: map[string][]lapi.PhysicalStorageDevice{
+				"node-1": {{Device: "/dev/sdb", Model: "HDD", Serial: "1"}, {Device: "/dev/sdc", Model: "HDD", Serial: "2"}},
+				"node-2": {{Device: "/dev/sdb", Model: "HDD", Serial: "3"}},
+			},
+		},
+	}
+
+	existing := func(devicePath string) piraeusv1.LinstorPhysicalDevice {
+		return piraeusv1.LinstorPhysicalDevice{
+			Spec:   piraeusv1.LinstorPhysicalDeviceSpec{NodeName: "node-1", DevicePath: devicePath},
+			Status: piraeusv1.LinstorPhysicalDeviceStatus{Model: "SSD", Available: true},
+		}
+	}
+
+	testcases := []struct {
+		name        string
+		storageList []lapi.PhysicalStorage
+		existing    []piraeusv1.LinstorPhysicalDevice
+		expected    map[string]piraeusv1.LinstorPhysicalDeviceStatus
+	}{
+		{
+			name:        "empty-devices",
+			storageList: storageList,
+			expected: map[string]piraeusv1.LinstorPhysicalDeviceStatus{
+				"node-1-sdb": {Size: resource.NewQuantity(10*1024*1024*1024, resource.BinarySI), Rotational: true, Model: "HDD", Serial: "1", ClaimedBy: "lvm", Available: true},
+				"node-1-sdc": {Size: resource.NewQuantity(10*1024*1024*1024, resource.BinarySI), Rotational: true, Model: "HDD", Serial: "2", Available: true},
+			},
+		},
+		{
+			name:     "claimed-device-in-use",
+			existing: []piraeusv1.LinstorPhysicalDevice{existing("/dev/sdb"), existing("/dev/sdd")},
+			expected: map[string]piraeusv1.LinstorPhysicalDeviceStatus{
+				"node-1-sdb": {Model: "SSD", ClaimedBy: "lvm"},
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := make(map[string]piraeusv1.LinstorPhysicalDeviceStatus)
+			for _, device := range physicalDevicesForNode(satelliteSet, "node-1", tcase.storageList, tcase.existing) {
+				actual[device.Name] = device.Status
+			}
+
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %+v, actual: %+v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestDevicePoolClaims(t *testing.T) {
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{
+		Spec: piraeusv1.LinstorSatelliteSetSpec{AutomaticStorageType: "LVM"},
+	}
+
+	sdb := &shared.DeviceInfo{Path: "/dev/sdb", Rotational: true}
+	nvme := &shared.DeviceInfo{Path: "/dev/nvme0n1"}
+
+	claims := devicePoolClaims(satelliteSet)
+	if claims(sdb) != "autopool-sdb" {
+		t.Errorf("expected: %q, actual: %q", "autopool-sdb", claims(sdb))
+	}
+
+	satelliteSet.Spec.AutomaticStorageDevices = &shared.AutomaticStorageDevices{
+		Include:          []*shared.DeviceFilter{{DevicePath: "/dev/nvme*"}},
+		PoolNameTemplate: "auto-${media}",
+	}
+
+	claims = devicePoolClaims(satelliteSet)
+	if claims(sdb) != "" {
+		t.Errorf("expected excluded device not to be claimed, actual: %q", claims(sdb))
+	}
+
+	if claims(nvme) != "auto-ssd" {
+		t.Errorf("expected: %q, actual: %q", "auto-ssd", claims(nvme))
+	}
+
+	satelliteSet.Spec.AutomaticStorageType = automaticStorageTypeNone
+
+	claims = devicePoolClaims(satelliteSet)
+	if claims(nvme) != "" {
+		t.Errorf("expected no claim, actual: %q", claims(nvme))
+	}
+}
+
+func TestAutomaticStoragePools(t *testing.T) {
+	devices := []shared.DeviceInfo{
+		{Path: "/dev/sdc", Rotational: true},
+		{Path: "/dev/sdb", Rotational: true},
+		{Path: "/dev/nvme0n1"},
+		{Path: "/dev/sdd", Model: "USB Stick", Serial: "1234"},
+	}
+
+	testcases := []struct {
+		name      string
+		selection *shared.AutomaticStorageDevices
+		expected  map[string][]string
+	}{
+		{
+			name: "default",
+			expected: map[string][]string{
+				"autopool-sdb":     {"/dev/sdb"},
+				"autopool-sdc":     {"/dev/sdc"},
+				"autopool-nvme0n1": {"/dev/nvme0n1"},
+				"autopool-sdd":     {"/dev/sdd"},
+			},
+		},
+		{
+			name: "grouped-by-media",
+			selection: &shared.AutomaticStorageDevices{
+				Exclude:          []*shared.DeviceFilter{{ID: "USB_Stick_*"}},
+				PoolNameTemplate: "${media}",
+			},
+			expected: map[string][]string{
+				"hdd": {"/dev/sdb", "/dev/sdc"},
+				"ssd": {"/dev/nvme0n1"},
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := automaticStoragePools(tcase.selection, devices)
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %v, actual: %v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestOperatorCreatedPool(t *testing.T) {
+	pools := []lapi.StoragePool{
+		{StoragePoolName: "lvm", Props: map[string]string{kubeSpec.LinstorRegistrationProperty: kubeSpec.Name}},
+		{StoragePoolName: "manual", Props: map[string]string{}},
+	}
+
+	testcases := map[string]bool{
+		"lvm":     true,
+		"manual":  false,
+		"missing": false,
+	}
+
+	for name, expected := range testcases {
+		actual := operatorCreatedPool(pools, name) != nil
+		if actual != expected {
+			t.Errorf("%s: expected: %v, actual: %v", name, expected, actual)
+		}
+	}
+}
+
+func TestPhysicalDeviceName(t *testing.T) {
+	testcases := map[[2]string]string{
+		{"node-1", "/dev/sdb"}:                      "node-1-sdb",
+		{"Node.Example.com", "/dev/nvme0n1"}:        "node.example.com-nvme0n1",
+		{"node-1", "/dev/disk/by-id/ata-Disk_1234"}: "node-1-disk-by-id-ata-disk-1234",
+		{"node-1", "/dev/mapper/vg--1-lv_1"}:        "node-1-mapper-vg--1-lv-1",
+	}
+
+	for input, expected := range testcases {
+		actual := physicalDeviceName(input[0], input[1])
+		if actual != expected {
+			t.Errorf("%v: expected: %q, actual: %q", input, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+// Preflight checks run in two init containers of every satellite pod. Host checks run first, so missing
+// prerequisites are reported before the kernel module injector tries to load DRBD. Checks of the loaded DRBD module
+// run after the injector, once DRBD is loaded. The results are written as JSON list of shared.PreflightCheck to the
+// termination message of the containers, so the operator can pick them up without needing access to the host. With
+// the "Block" policy, the containers fail on any failed check, which prevents the satellite from starting.
+
+const (
+	preflightContainerName     = "preflight"
+	preflightDRBDContainerName = "preflight-drbd"
+	// preflightBlockEnv makes the preflight script exit with an error if any check failed.
+	preflightBlockEnv = "PREFLIGHT_BLOCK"
+	// preflightCheckHeadersEnv enables the kernel headers check, only needed when compiling DRBD.
+	preflightCheckHeadersEnv = "PREFLIGHT_CHECK_HEADERS"
+)
+
+// preflightScript wraps the checks, reporting the results and failing if needed. Checks call "add <name> <result>
+// <message>". Messages may not contain '"' or '\', as they are not escaped.
+func preflightScript(checks string) string {
+	return fmt.Sprintf(`
+results=""
+failed=no
+
+add() {
+	if [ -n "$results" ]; then
+		results="$results,"
+	fi
+	results="$results{\"name\":\"$1\",\"result\":\"$2\",\"message\":\"$3\"}"
+	if [ "$2" = Fail ]; then
+		failed=yes
+	fi
+}
+%[1]s
+echo "[$results]" | tee %[2]s
+
+if [ "$failed" = yes ] && [ "${%[3]s:-}" = yes ]; then
+	exit 1
+fi
+`, checks, corev1.TerminationMessagePathDefault, preflightBlockEnv)
+}
+
+// preflightHostChecks check the host configuration, independent of DRBD.
+var preflightHostChecks = fmt.Sprintf(`
+etc=%[1]s
+if [ ! -e "$etc/multipath.conf" ]; then
+	add Multipath Skip "multipath not configured"
+elif grep -qs drbd "$etc/multipath.conf" "$etc"/multipath/conf.d/*.conf; then
+	add Multipath Pass ""
+else
+	add Multipath Warn "DRBD devices not blacklisted in multipath configuration, multipathd may keep them open"
+fi
+
+if [ "${%[2]s:-}" != yes ]; then
+	add KernelHeaders Skip "not compiling DRBD"
+elif [ -e "/lib/modules/$(uname -r)/build" ]; then
+	add KernelHeaders Pass ""
+else
+	add KernelHeaders Warn "no kernel headers found for $(uname -r)"
+fi
+
+if [ ! -e "$etc/lvm/lvm.conf" ]; then
+	add LVMFilter Skip "LVM not configured"
+elif grep -Eqs '^[[:space:]]*(global_)?filter[[:space:]]*=.*drbd' "$etc/lvm/lvm.conf"; then
+	add LVMFilter Pass ""
+else
+	add LVMFilter Warn "LVM does not filter DRBD devices, LVM on top of DRBD may be activated on the wrong device"
+fi
+`, kubeSpec.HostEtcMountPath, preflightCheckHeadersEnv)
+
+// preflightDRBDChecks check the loaded DRBD kernel module.
+const preflightDRBDChecks = `
+helper=/sys/module/drbd/parameters/usermode_helper
+if [ ! -e "$helper" ]; then
+	add UsermodeHelper Skip "DRBD kernel module not loaded"
+elif [ "$(cat "$helper")" = disabled ]; then
+	add UsermodeHelper Pass ""
+else
+	add UsermodeHelper Fail "DRBD usermode_helper is $(cat "$helper"), needs to be disabled"
+fi
+`
+
+// daemonSetWithPreflightChecks adds the preflight init containers: the host checks run before all other init
+// containers, the DRBD checks after all other init containers, so they see the module loaded by the injector.
+func daemonSetWithPreflightChecks(ds *apps.DaemonSet, satelliteSet *piraeusv1.LinstorSatelliteSet) *apps.DaemonSet {
+	policy := satelliteSet.Spec.PreflightPolicy
+	if policy == shared.PreflightNone {
+		return ds
+	}
+
+	block := "no"
+	if policy == shared.PreflightBlock {
+		block = "yes"
+	}
+
+	checkHeaders := "no"
+	if satelliteSet.Spec.KernelModuleInjectionMode == shared.ModuleInjectionCompile {
+		checkHeaders = "yes"
+	}
+
+	hostContainer := corev1.Container{
+		Name:            preflightContainerName,
+		Image:           satelliteSet.Spec.SatelliteImage,
+		ImagePullPolicy: satelliteSet.Spec.ImagePullPolicy,
+		Command:         []string{"sh", "-c", preflightScript(preflightHostChecks)},
+		Env: []corev1.EnvVar{
+			{Name: preflightBlockEnv, Value: block},
+			{Name: preflightCheckHeadersEnv, Value: checkHeaders},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      kubeSpec.HostEtcDirName,
+				MountPath: kubeSpec.HostEtcMountPath,
+				ReadOnly:  true,
+			},
+			{
+				Name:      kubeSpec.ModulesDirName,
+				MountPath: kubeSpec.ModulesDir,
+				ReadOnly:  true,
+			},
+		},
+	}
+
+	if checkHeaders == "yes" {
+		// The build directory of a kernel usually links to the sources in /usr/src. The volume is already added by
+		// the kernel module injection.
+		hostContainer.VolumeMounts = append(hostContainer.VolumeMounts, corev1.VolumeMount{
+			Name:      kubeSpec.SrcDirName,
+			MountPath: kubeSpec.SrcDir,
+			ReadOnly:  true,
+		})
+	}
+
+	drbdContainer := corev1.Container{
+		Name:            preflightDRBDContainerName,
+		Image:           satelliteSet.Spec.SatelliteImage,
+		ImagePullPolicy: satelliteSet.Spec.ImagePullPolicy,
+		Command:         []string{"sh", "-c", preflightScript(preflightDRBDChecks)},
+		Env: []corev1.EnvVar{
+			{Name: preflightBlockEnv, Value: block},
+		},
+	}
+
+	initContainers := append([]corev1.Container{hostContainer}, ds.Spec.Template.Spec.InitContainers...)
+	ds.Spec.Template.Spec.InitContainers = append(initContainers, drbdContainer)
+
+	ds.Spec.Template.Spec.Volumes = append(ds.Spec.Template.Spec.Volumes, corev1.Volume{
+		Name: kubeSpec.HostEtcDirName,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: kubeSpec.HostEtcDir,
+				Type: &kubeSpec.HostPathDirectoryType,
+			},
+		},
+	})
+
+	return ds
+}
+
+// preflightChecks returns the results of the preflight checks of a satellite pod, or nil if they are not available.
+// Results of the DRBD checks are added once the container running them terminated.
+//
+// While a blocking preflight container is restarted, the results of the last run are returned.
+func preflightChecks(pod *corev1.Pod) []*shared.PreflightCheck {
+	var result []*shared.PreflightCheck
+
+	for i := range pod.Status.InitContainerStatuses {
+		containerStatus := &pod.Status.InitContainerStatuses[i]
+		if containerStatus.Name != preflightContainerName && containerStatus.Name != preflightDRBDContainerName {
+			continue
+		}
+
+		terminated := containerStatus.State.Terminated
+		if terminated == nil {
+			terminated = containerStatus.LastTerminationState.Terminated
+		}
+
+		if terminated == nil {
+			continue
+		}
+
+		var checks []*shared.PreflightCheck
+
+		err := json.Unmarshal([]byte(terminated.Message), &checks)
+		if err != nil {
+			log.WithFields(logrus.Fields{"pod": pod.Name, "container": containerStatus.Name}).Warnf("could not parse preflight check results: %v", err)
+			continue
+		}
+
+		result = append(result, checks...)
+	}
+
+	return result
+}
+
+// recordPreflightEvents emits events on the kubernetes node for all failed checks or checks with warnings. Events
+// are only emitted when the results changed since the last report, to not flood the node with events.
+func recordPreflightEvents(recorder record.EventRecorder, k8sNode *corev1.Node, previous, current []*shared.PreflightCheck) {
+	if k8sNode == nil || current == nil || reflect.DeepEqual(previous, current) {
+		return
+	}
+
+	var failed, warnings []string
+
+	for _, check := range current {
+		switch check.Result {
+		case shared.PreflightFail:
+			failed = append(failed, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		case shared.PreflightWarn:
+			warnings = append(warnings, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		}
+	}
+
+	if len(failed) != 0 {
+		recorder.Eventf(k8sNode, corev1.EventTypeWarning, "PreflightCheckFailed", "DRBD preflight checks failed: %s", strings.Join(failed, "; "))
+	}
+
+	if len(warnings) != 0 {
+		recorder.Eventf(k8sNode, corev1.EventTypeWarning, "PreflightCheckWarning", "DRBD preflight checks reported warnings: %s", strings.Join(warnings, "; "))
+	}
+}
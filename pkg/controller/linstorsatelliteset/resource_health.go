@@ -0,0 +1,193 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"regexp"
+	"sort"
+
+	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/LINBIT/golinstor/devicelayerkind"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+const (
+	diskStateInconsistent = "Inconsistent"
+	diskStateSyncTarget   = "SyncTarget"
+)
+
+// diskStateWithProgress matches disk states that include the sync progress, for example "SyncTarget(42.10%)".
+var diskStateWithProgress = regexp.MustCompile(`^(\w+)\(([0-9.]+)%\)$`)
+
+// splitDiskState returns the disk state without the sync progress, and the progress if reported.
+func splitDiskState(diskState string) (string, string) {
+	match := diskStateWithProgress.FindStringSubmatch(diskState)
+	if match == nil {
+		return diskState, ""
+	}
+
+	return match[1], match[2] + "%"
+}
+
+// replicaHealthy returns true if all volumes of the replica are UpToDate, or Diskless for a diskless replica.
+func replicaHealthy(res *lapi.ResourceWithVolumes) bool {
+	expected := lc.DiskStateUpToDate
+	if !lc.IsDiskful(res) {
+		expected = diskStateDiskless
+	}
+
+	if len(res.Volumes) == 0 {
+		return false
+	}
+
+	for i := range res.Volumes {
+		state, _ := splitDiskState(res.Volumes[i].State.DiskState)
+		if state != expected {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasQuorum estimates DRBD quorum of the replica from its connections: together with the replica itself, more than
+// half of all replicas need to be connected. Resources without DRBD layer always have quorum.
+func hasQuorum(res *lapi.ResourceWithVolumes) bool {
+	if res.LayerObject.Type != devicelayerkind.Drbd {
+		return true
+	}
+
+	connected := 1
+
+	for _, conn := range res.LayerObject.Drbd.Connections {
+		if conn.Connected {
+			connected++
+		}
+	}
+
+	return connected*2 > len(res.LayerObject.Drbd.Connections)+1
+}
+
+// resourceHealthDetailLimit returns the maximum number of degraded resources to list per satellite.
+func resourceHealthDetailLimit(satelliteSet *piraeusv1.LinstorSatelliteSet) int {
+	if satelliteSet.Spec.ResourceHealthDetailLimit == nil {
+		return defaultResourceHealthDetailLimit
+	}
+
+	return int(*satelliteSet.Spec.ResourceHealthDetailLimit)
+}
+
+// resourceHealth aggregates the state of all resources on the node. At most `limit` degraded resources are listed.
+func resourceHealth(nodeName string, resources []lapi.ResourceWithVolumes, limit int) *shared.ResourceHealthStatus {
+	byName := make(map[string][]*lapi.ResourceWithVolumes)
+	for i := range resources {
+		byName[resources[i].Name] = append(byName[resources[i].Name], &resources[i])
+	}
+
+	health := &shared.ResourceHealthStatus{}
+
+	var degraded []*shared.ResourceHealthDetail
+
+	for i := range resources {
+		res := &resources[i]
+		if res.NodeName != nodeName {
+			continue
+		}
+
+		health.Resources++
+
+		detail := &shared.ResourceHealthDetail{
+			Name:     res.Name,
+			NoQuorum: !hasQuorum(res),
+		}
+
+		volumes := append([]lapi.Volume(nil), res.Volumes...)
+		sort.Slice(volumes, func(i, j int) bool {
+			return volumes[i].VolumeNumber < volumes[j].VolumeNumber
+		})
+
+		syncing := false
+
+		for j := range volumes {
+			state, progress := splitDiskState(volumes[j].State.DiskState)
+			if state == "" {
+				state = "Unknown"
+			}
+
+			if health.DiskStates == nil {
+				health.DiskStates = make(map[string]int32)
+			}
+
+			health.DiskStates[state]++
+			detail.DiskStates = append(detail.DiskStates, state)
+
+			if progress != "" {
+				detail.SyncProgress = progress
+			}
+
+			if state == diskStateSyncTarget || progress != "" || (state == diskStateInconsistent && hasUpToDatePeer(byName[res.Name], nodeName)) {
+				syncing = true
+			}
+		}
+
+		if syncing {
+			health.Syncing++
+		}
+
+		if detail.NoQuorum {
+			health.NoQuorum++
+		}
+
+		if detail.NoQuorum || !replicaHealthy(res) {
+			health.Degraded++
+			degraded = append(degraded, detail)
+		}
+	}
+
+	sort.Slice(degraded, func(i, j int) bool {
+		return degraded[i].Name < degraded[j].Name
+	})
+
+	if limit < 0 {
+		limit = 0
+	}
+
+	if len(degraded) > limit {
+		degraded = degraded[:limit]
+	}
+
+	if len(degraded) != 0 {
+		health.DegradedResources = degraded
+	}
+
+	return health
+}
+
+// hasUpToDatePeer returns true if a diskful replica on another node is UpToDate.
+func hasUpToDatePeer(replicas []*lapi.ResourceWithVolumes, nodeName string) bool {
+	for _, replica := range replicas {
+		if replica.NodeName != nodeName && lc.IsDiskful(replica) && replicaHealthy(replica) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,125 @@
+package linstorsatelliteset
+
+import (
+	"reflect"
+	"testing"
+
+	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/LINBIT/golinstor/devicelayerkind"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+)
+
+func TestResourceHealth(t *testing.T) {
+	replica := func(name, node string, connected map[string]bool, diskStates []string, flags ...string) lapi.ResourceWithVolumes {
+		res := lapi.ResourceWithVolumes{
+			Resource: lapi.Resource{
+				Name:     name,
+				NodeName: node,
+				Flags:    flags,
+				LayerObject: lapi.ResourceLayer{
+					Type: devicelayerkind.Drbd,
+					Drbd: lapi.DrbdResource{Connections: map[string]lapi.DrbdConnection{}},
+				},
+			},
+		}
+
+		for peer, ok := range connected {
+			res.LayerObject.Drbd.Connections[peer] = lapi.DrbdConnection{Connected: ok}
+		}
+
+		for i, state := range diskStates {
+			res.Volumes = append(res.Volumes, lapi.Volume{VolumeNumber: int32(i), State: lapi.VolumeState{DiskState: state}})
+		}
+
+		return res
+	}
+
+	resources := []lapi.ResourceWithVolumes{
+		replica("healthy", "node-1", map[string]bool{"node-2": true}, []string{"UpToDate", "UpToDate"}),
+		replica("healthy", "node-2", map[string]bool{"node-1": true}, []string{"UpToDate", "UpToDate"}),
+		replica("syncing", "node-1", map[string]bool{"node-2": true}, []string{"SyncTarget(42.10%)"}),
+		replica("syncing", "node-2", map[string]bool{"node-1": true}, []string{"UpToDate"}),
+		replica("diskless", "node-1", map[string]bool{"node-2": true}, []string{"Diskless"}, "DISKLESS"),
+		replica("diskless", "node-2", map[string]bool{"node-1": true}, []string{"UpToDate"}),
+		replica("no-quorum", "node-1", map[string]bool{"node-2": false, "node-3": false}, []string{"Outdated"}),
+		replica("no-quorum", "node-2", map[string]bool{"node-1": false, "node-3": true}, []string{"UpToDate"}),
+		replica("no-quorum", "node-3", map[string]bool{"node-1": false, "node-2": true}, []string{"UpToDate"}),
+		replica("inconsistent", "node-1", map[string]bool{"node-2": true}, []string{"Inconsistent"}),
+		replica("inconsistent", "node-2", map[string]bool{"node-1": true}, []string{"UpToDate"}),
+	}
+
+	testcases := []struct {
+		name     string
+		limit    int
+		expected *shared.ResourceHealthStatus
+	}{
+		{
+			name:  "all-details",
+			limit: 10,
+			expected: &shared.ResourceHealthStatus{
+				Resources:  5,
+				DiskStates: map[string]int32{"UpToDate": 2, "SyncTarget": 1, "Diskless": 1, "Outdated": 1, "Inconsistent": 1},
+				NoQuorum:   1,
+				Syncing:    2,
+				Degraded:   3,
+				DegradedResources: []*shared.ResourceHealthDetail{
+					{Name: "inconsistent", DiskStates: []string{"Inconsistent"}},
+					{Name: "no-quorum", DiskStates: []string{"Outdated"}, NoQuorum: true},
+					{Name: "syncing", DiskStates: []string{"SyncTarget"}, SyncProgress: "42.10%"},
+				},
+			},
+		},
+		{
+			name:  "limited-details",
+			limit: 1,
+			expected: &shared.ResourceHealthStatus{
+				Resources:  5,
+				DiskStates: map[string]int32{"UpToDate": 2, "SyncTarget": 1, "Diskless": 1, "Outdated": 1, "Inconsistent": 1},
+				NoQuorum:   1,
+				Syncing:    2,
+				Degraded:   3,
+				DegradedResources: []*shared.ResourceHealthDetail{
+					{Name: "inconsistent", DiskStates: []string{"Inconsistent"}},
+				},
+			},
+		},
+		{
+			name:  "counters-only",
+			limit: 0,
+			expected: &shared.ResourceHealthStatus{
+				Resources:  5,
+				DiskStates: map[string]int32{"UpToDate": 2, "SyncTarget": 1, "Diskless": 1, "Outdated": 1, "Inconsistent": 1},
+				NoQuorum:   1,
+				Syncing:    2,
+				Degraded:   3,
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := resourceHealth("node-1", resources, tcase.limit)
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %+v, actual: %+v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestSplitDiskState(t *testing.T) {
+	testcases := map[string][2]string{
+		"UpToDate":           {"UpToDate", ""},
+		"SyncTarget(42.10%)": {"SyncTarget", "42.10%"},
+		"Inconsistent(5%)":   {"Inconsistent", "5%"},
+		"":                   {"", ""},
+	}
+
+	for input, expected := range testcases {
+		state, progress := splitDiskState(input)
+		if state != expected[0] || progress != expected[1] {
+			t.Errorf("%q: expected: %v, actual: [%s %s]", input, expected, state, progress)
+		}
+	}
+}
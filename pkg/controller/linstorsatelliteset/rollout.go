@@ -0,0 +1,410 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	linstor "github.com/LINBIT/golinstor"
+	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/LINBIT/golinstor/devicelayerkind"
+	"github.com/sirupsen/logrus"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	mdutil "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/metadata/util"
+	"github.com/piraeusdatastore/piraeus-operator/pkg/k8s/reconcileutil"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+// With the "Managed" update strategy, the satellite DaemonSet uses the "OnDelete" strategy. The operator replaces
+// outdated satellite pods itself, only deleting a pod while fewer than MaxUnavailable satellites are unavailable and
+// all resources with a replica on its node are in sync. Nodes moving to
+// another kernel module injection group or node override are relabelled at the same point, as the new labels move the
+// satellite pod to another DaemonSet.
+
+// podTemplateGenerationLabel is set by the DaemonSet controller on every pod. It matches the
+// "deprecated.daemonset.template.generation" annotation of the DaemonSet that created the pod.
+const podTemplateGenerationLabel = "pod-template-generation"
+
+// diskStateDiskless is the DRBD disk state of a diskless replica.
+const diskStateDiskless = "Diskless"
+
+// daemonSetUpdateStrategy returns the DaemonSet strategy matching the satellite update strategy.
+func daemonSetUpdateStrategy(strategy *piraeusv1.SatelliteUpdateStrategy) apps.DaemonSetUpdateStrategy {
+	if strategy != nil && strategy.Type == piraeusv1.SatelliteUpdateManaged {
+		return apps.DaemonSetUpdateStrategy{Type: apps.OnDeleteDaemonSetStrategyType}
+	}
+
+	return apps.DaemonSetUpdateStrategy{
+		Type: apps.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &apps.RollingUpdateDaemonSet{
+			MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"},
+		},
+	}
+}
+
+// reconcileSatelliteRollout replaces outdated satellite pods when using the "Managed" update strategy.
+//
+// Pods are only replaced while fewer than MaxUnavailable satellites are unavailable. Pods on nodes with outdated node
+// group labels are replaced by updating the labels. While outdated pods remain, a TemporaryError is returned, so the
+// progress is checked again soon.
+func (r *ReconcileLinstorSatelliteSet) reconcileSatelliteRollout(ctx context.Context, satelliteSet *piraeusv1.LinstorSatelliteSet) error {
+	logger := log.WithFields(logrus.Fields{
+		"Name":      satelliteSet.Name,
+		"Namespace": satelliteSet.Namespace,
+		"Op":        "reconcileSatelliteRollout",
+	})
+
+	strategy := satelliteSet.Spec.UpdateStrategy
+	if strategy == nil || strategy.Type != piraeusv1.SatelliteUpdateManaged {
+		satelliteSet.Status.Rollout = nil
+
+		return nil
+	}
+
+	daemonSets := &apps.DaemonSetList{}
+
+	err := r.client.List(ctx, daemonSets, client.InNamespace(satelliteSet.Namespace), client.MatchingLabels(getObjectMeta(satelliteSet, "%s").Labels))
+	if err != nil {
+		return fmt.Errorf("failed to list satellite daemonsets: %w", err)
+	}
+
+	pods, err := r.getAllNodePods(ctx, satelliteSet)
+	if err != nil {
+		return fmt.Errorf("failed to list satellite pods: %w", err)
+	}
+
+	linstorClient, err := lc.NewHighLevelLinstorClientFromConfig(
+		satelliteSet.Spec.ControllerEndpoint,
+		&satelliteSet.Spec.LinstorClientConfig,
+		lc.NamedSecret(ctx, r.client, satelliteSet.Spec.LinstorHttpsClientSecret),
+	)
+	if err != nil {
+		return err
+	}
+
+	if !linstorClient.ControllerReachable(ctx) {
+		// Already reported by the per-node reconciliation. Without the controller, no satellite counts as available.
+		logger.Debug("controller not reachable, not replacing satellite pods")
+
+		return nil
+	}
+
+	nodes, err := linstorClient.Nodes.GetAll(ctx)
+	if err != nil && err != lapi.NotFoundError {
+		return fmt.Errorf("failed to list satellites: %w", err)
+	}
+
+	resources, err := linstorClient.Resources.GetResourceView(ctx)
+	if err != nil && err != lapi.NotFoundError {
+		return fmt.Errorf("failed to list resources: %w", err)
+	}
+
+	k8sNodes := &corev1.NodeList{}
+
+	err = r.client.List(ctx, k8sNodes)
+	if err != nil {
+		return fmt.Errorf("failed to get kubernetes nodes: %w", err)
+	}
+
+	satellites := rolloutSatellites(satelliteSet, nodes, k8sNodes.Items)
+	regroup := nodesWithOutdatedGroups(satelliteSet, k8sNodes.Items, pods)
+
+	plan := planSatelliteRollout(daemonSets.Items, pods, satellites, resources, regroup, int(strategy.MaxUnavailable))
+
+	satelliteSet.Status.Rollout = &piraeusv1.SatelliteRolloutStatus{
+		UpdatedPods:       int32(plan.updated),
+		TotalPods:         int32(len(pods)),
+		Paused:            strategy.Paused,
+		UnavailableNodes:  plan.unavailable,
+		BlockingResources: plan.blocking,
+	}
+
+	if plan.updated == len(pods) {
+		logger.Debug("all satellite pods up to date")
+
+		return nil
+	}
+
+	if strategy.Paused {
+		logger.Info("satellite rollout paused")
+
+		return nil
+	}
+
+	for _, pod := range plan.replace {
+		if k8sNode, ok := regroup[pod.Spec.NodeName]; ok {
+			wanted := nodeGroupLabels(satelliteSet, k8sNode)
+
+			logger.WithFields(logrus.Fields{
+				"pod":      pod.Name,
+				"node":     k8sNode.Name,
+				"group":    wanted[kubeSpec.KernelModuleInjectionGroupLabel],
+				"override": wanted[kubeSpec.SatelliteNodeOverrideLabel],
+			}).Info("update node groups")
+
+			// The old DaemonSet no longer selects the node and removes the pod, the pod is deleted below so it is
+			// immediately reported as unavailable.
+			err := r.setNodeGroupLabels(ctx, k8sNode, wanted)
+			if err != nil {
+				return err
+			}
+		}
+
+		logger.WithField("pod", pod.Name).Info("replace outdated satellite pod")
+
+		err := r.client.Delete(ctx, pod, client.Preconditions{UID: &pod.UID})
+		if err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to replace satellite pod '%s': %w", pod.Name, err)
+		}
+
+		r.recorder.Eventf(satelliteSet, corev1.EventTypeNormal, "SatelliteReplaced", "Replacing outdated satellite on node '%s'", pod.Spec.NodeName)
+	}
+
+	return &reconcileutil.TemporaryError{
+		Source:       fmt.Errorf("satellite rollout in progress: %d of %d pods updated", plan.updated, len(pods)),
+		RequeueAfter: connectionRetrySeconds * time.Second,
+	}
+}
+
+// rolloutSatellites returns the satellites of the set that should be running a satellite pod: satellites registered
+// by the set on kubernetes nodes selected by the set. Satellites without kubernetes node are handled by
+// removeDanglingSatellites.
+func rolloutSatellites(satelliteSet *piraeusv1.LinstorSatelliteSet, nodes []lapi.Node, k8sNodes []corev1.Node) []lapi.Node {
+	var result []lapi.Node
+
+	for i := range nodes {
+		node := &nodes[i]
+
+		if node.Type != lc.Satellite || !ownedBySatelliteSet(node, satelliteSet) {
+			continue
+		}
+
+		k8sNode := findK8sNode(k8sNodes, node.Name)
+		if k8sNode == nil || !selectsNode(satelliteSet, k8sNode) {
+			continue
+		}
+
+		result = append(result, *node)
+	}
+
+	return result
+}
+
+// nodesWithOutdatedGroups returns the kubernetes nodes running a satellite pod, that need to be moved to another node
+// group. See reconcileNodeGroups.
+func nodesWithOutdatedGroups(satelliteSet *piraeusv1.LinstorSatelliteSet, k8sNodes []corev1.Node, pods []corev1.Pod) map[string]*corev1.Node {
+	satelliteNodes := make(map[string]bool, len(pods))
+	for i := range pods {
+		satelliteNodes[pods[i].Spec.NodeName] = true
+	}
+
+	result := make(map[string]*corev1.Node)
+
+	for i := range k8sNodes {
+		k8sNode := &k8sNodes[i]
+
+		if !satelliteNodes[k8sNode.Name] || !selectsNode(satelliteSet, k8sNode) {
+			continue
+		}
+
+		if !nodeGroupLabelsMatch(k8sNode, nodeGroupLabels(satelliteSet, k8sNode)) {
+			result[k8sNode.Name] = k8sNode
+		}
+	}
+
+	return result
+}
+
+type satelliteRolloutPlan struct {
+	// Number of satellite pods running the current pod template.
+	updated int
+	// Nodes with an unavailable satellite, sorted by name.
+	unavailable []string
+	// Resources not in sync, that have a replica on a node with an outdated satellite, sorted by name.
+	blocking []string
+	// Outdated pods to delete, so the DaemonSet controller replaces them.
+	replace []*corev1.Pod
+}
+
+// planSatelliteRollout determines which outdated satellite pods can be replaced. Pods on nodes in regroup are
+// outdated, regardless of their pod template. Satellites on nodes without a pod, for example because the pod was just
+// deleted, are unavailable.
+//
+// Outdated pods that are not ready are always replaced, as they can't make the situation worse. Other outdated pods
+// are replaced in order of their node name, as long as they are available and the number of unavailable satellites
+// stays within maxUnavailable. A pod is never replaced while a resource with a replica on its node is not in sync, as
+// the replica may be needed by its peers. Resources not in sync on other nodes do not block the pod.
+func planSatelliteRollout(daemonSets []apps.DaemonSet, pods []corev1.Pod, nodes []lapi.Node, resources []lapi.ResourceWithVolumes, regroup map[string]*corev1.Node, maxUnavailable int) satelliteRolloutPlan {
+	owners := make(map[types.UID]*apps.DaemonSet, len(daemonSets))
+	for i := range daemonSets {
+		owners[daemonSets[i].UID] = &daemonSets[i]
+	}
+
+	onlineNodes := make(map[string]bool, len(nodes))
+	for i := range nodes {
+		onlineNodes[nodes[i].Name] = nodes[i].ConnectionStatus == lc.Online
+	}
+
+	unsettled := sets.NewString()
+	for i := range resources {
+		if !drbdResourceSettled(&resources[i]) {
+			unsettled.Insert(resources[i].Name)
+		}
+	}
+
+	unsettledByNode := make(map[string][]string)
+	for i := range resources {
+		if unsettled.Has(resources[i].Name) {
+			unsettledByNode[resources[i].NodeName] = append(unsettledByNode[resources[i].NodeName], resources[i].Name)
+		}
+	}
+
+	blocking := sets.NewString()
+
+	sorted := make([]*corev1.Pod, len(pods))
+	for i := range pods {
+		sorted[i] = &pods[i]
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Spec.NodeName < sorted[j].Spec.NodeName
+	})
+
+	plan := satelliteRolloutPlan{}
+
+	var candidates []*corev1.Pod
+
+	for _, pod := range sorted {
+		outdated := true
+		if ref := metav1.GetControllerOf(pod); ref != nil && owners[ref.UID] != nil {
+			outdated = podOutdated(owners[ref.UID], pod)
+		}
+		if _, ok := regroup[pod.Spec.NodeName]; ok {
+			outdated = true
+		}
+		if !outdated {
+			plan.updated++
+		}
+
+		available := pod.DeletionTimestamp == nil && podReady(pod) && onlineNodes[pod.Spec.NodeName]
+		if !available {
+			plan.unavailable = append(plan.unavailable, pod.Spec.NodeName)
+		}
+
+		switch {
+		case !outdated || pod.DeletionTimestamp != nil:
+		case !podReady(pod):
+			plan.replace = append(plan.replace, pod)
+		case !available:
+		case len(unsettledByNode[pod.Spec.NodeName]) != 0:
+			blocking.Insert(unsettledByNode[pod.Spec.NodeName]...)
+		default:
+			candidates = append(candidates, pod)
+		}
+	}
+
+	if blocking.Len() != 0 {
+		plan.blocking = blocking.List()
+	}
+
+	podNodes := make(map[string]bool, len(pods))
+	for i := range pods {
+		podNodes[pods[i].Spec.NodeName] = true
+	}
+
+	for i := range nodes {
+		if !podNodes[nodes[i].Name] {
+			plan.unavailable = append(plan.unavailable, nodes[i].Name)
+		}
+	}
+
+	sort.Strings(plan.unavailable)
+
+	budget := maxUnavailable - len(plan.unavailable)
+	for i := 0; i < budget && i < len(candidates); i++ {
+		plan.replace = append(plan.replace, candidates[i])
+	}
+
+	return plan
+}
+
+// podOutdated returns true if the pod was not created from the current pod template of the DaemonSet.
+func podOutdated(ds *apps.DaemonSet, pod *corev1.Pod) bool {
+	// Pods orphaned when the DaemonSet was recreated are adopted by the new DaemonSet, but may carry a matching
+	// generation label.
+	if pod.CreationTimestamp.Before(&ds.CreationTimestamp) {
+		return true
+	}
+
+	generation, ok := ds.Annotations[apps.DeprecatedTemplateGeneration]
+	if !ok {
+		return false
+	}
+
+	return pod.Labels[podTemplateGenerationLabel] != generation
+}
+
+// drbdResourceSettled returns true if all volumes of the replica are UpToDate, or Diskless for diskless replicas,
+// and all DRBD connections are established.
+func drbdResourceSettled(res *lapi.ResourceWithVolumes) bool {
+	if res.LayerObject.Type != devicelayerkind.Drbd || mdutil.SliceContains(res.Flags, linstor.FlagDelete) {
+		return true
+	}
+
+	wantedState := lc.DiskStateUpToDate
+	if !lc.IsDiskful(res) {
+		wantedState = diskStateDiskless
+	}
+
+	for i := range res.Volumes {
+		if res.Volumes[i].State.DiskState != wantedState {
+			return false
+		}
+	}
+
+	for _, conn := range res.LayerObject.Drbd.Connections {
+		if !conn.Connected {
+			return false
+		}
+	}
+
+	return true
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
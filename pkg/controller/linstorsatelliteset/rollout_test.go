@@ -0,0 +1,337 @@
+package linstorsatelliteset
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/LINBIT/golinstor/devicelayerkind"
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+func TestPlanSatelliteRollout(t *testing.T) {
+	created := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	yes := true
+
+	daemonSets := []apps.DaemonSet{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "satellite",
+				UID:               "satellite-uid",
+				CreationTimestamp: created,
+				Annotations:       map[string]string{apps.DeprecatedTemplateGeneration: "2"},
+			},
+		},
+	}
+
+	pod := func(node, generation string, ready bool) corev1.Pod {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "satellite-" + node,
+				CreationTimestamp: created,
+				Labels:            map[string]string{podTemplateGenerationLabel: generation},
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "DaemonSet", Name: "satellite", UID: "satellite-uid", Controller: &yes},
+				},
+			},
+			Spec:   corev1.PodSpec{NodeName: node},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+		}
+	}
+
+	online := func(names ...string) []lapi.Node {
+		var nodes []lapi.Node
+		for _, name := range names {
+			nodes = append(nodes, lapi.Node{Name: name, ConnectionStatus: lc.Online})
+		}
+
+		return nodes
+	}
+
+	syncing := []lapi.ResourceWithVolumes{
+		drbdReplica("node-a", "UpToDate", true),
+		drbdReplica("node-b", "Inconsistent", true),
+	}
+
+	syncingOnOutdated := []lapi.ResourceWithVolumes{
+		drbdReplica("node-a", "UpToDate", true),
+		drbdReplica("node-b", "Inconsistent", true),
+		drbdReplica("node-c", "UpToDate", true),
+	}
+
+	orphaned := pod("node-b", "2", true)
+	orphaned.OwnerReferences = nil
+
+	testcases := []struct {
+		name                string
+		pods                []corev1.Pod
+		nodes               []lapi.Node
+		resources           []lapi.ResourceWithVolumes
+		regroup             map[string]*corev1.Node
+		maxUnavailable      int
+		expectedUpdated     int
+		expectedUnavailable []string
+		expectedReplace     []string
+		expectedBlocking    []string
+	}{
+		{
+			name:            "all-updated",
+			pods:            []corev1.Pod{pod("node-a", "2", true), pod("node-b", "2", true)},
+			nodes:           online("node-a", "node-b"),
+			maxUnavailable:  1,
+			expectedUpdated: 2,
+		},
+		{
+			name:            "replace-first-node",
+			pods:            []corev1.Pod{pod("node-c", "1", true), pod("node-b", "1", true), pod("node-a", "1", true)},
+			nodes:           online("node-a", "node-b", "node-c"),
+			maxUnavailable:  1,
+			expectedReplace: []string{"satellite-node-a"},
+		},
+		{
+			name:            "replace-multiple-nodes",
+			pods:            []corev1.Pod{pod("node-a", "1", true), pod("node-b", "1", true), pod("node-c", "1", true)},
+			nodes:           online("node-a", "node-b", "node-c"),
+			maxUnavailable:  2,
+			expectedReplace: []string{"satellite-node-a", "satellite-node-b"},
+		},
+		{
+			name:                "wait-for-updated-pod",
+			pods:                []corev1.Pod{pod("node-a", "2", false), pod("node-b", "1", true)},
+			nodes:               online("node-a", "node-b"),
+			maxUnavailable:      1,
+			expectedUpdated:     1,
+			expectedUnavailable: []string{"node-a"},
+		},
+		{
+			name:                "wait-for-offline-satellite",
+			pods:                []corev1.Pod{pod("node-a", "2", true), pod("node-b", "1", true)},
+			nodes:               online("node-b"),
+			maxUnavailable:      1,
+			expectedUpdated:     1,
+			expectedUnavailable: []string{"node-a"},
+		},
+		{
+			name:                "wait-for-recreated-pod",
+			pods:                []corev1.Pod{pod("node-a", "2", true), pod("node-c", "1", true)},
+			nodes:               online("node-a", "node-b", "node-c"),
+			maxUnavailable:      1,
+			expectedUpdated:     1,
+			expectedUnavailable: []string{"node-b"},
+		},
+		{
+			name:             "wait-for-resync",
+			pods:             []corev1.Pod{pod("node-a", "2", true), pod("node-b", "1", true), pod("node-c", "1", true)},
+			nodes:            online("node-a", "node-b", "node-c"),
+			resources:        syncingOnOutdated,
+			maxUnavailable:   1,
+			expectedUpdated:  1,
+			expectedBlocking: []string{"res1"},
+		},
+		{
+			name:             "replace-node-without-syncing-replica",
+			pods:             []corev1.Pod{pod("node-a", "2", true), pod("node-b", "1", true), pod("node-c", "1", true)},
+			nodes:            online("node-a", "node-b", "node-c"),
+			resources:        syncing,
+			maxUnavailable:   1,
+			expectedUpdated:  1,
+			expectedReplace:  []string{"satellite-node-c"},
+			expectedBlocking: []string{"res1"},
+		},
+		{
+			name:            "regroup-updated-pod",
+			pods:            []corev1.Pod{pod("node-a", "2", true), pod("node-b", "2", true), pod("node-c", "2", true)},
+			nodes:           online("node-a", "node-b", "node-c"),
+			regroup:         map[string]*corev1.Node{"node-b": {}, "node-c": {}},
+			maxUnavailable:  1,
+			expectedUpdated: 1,
+			expectedReplace: []string{"satellite-node-b"},
+		},
+		{
+			name:                "wait-for-regrouped-pod",
+			pods:                []corev1.Pod{pod("node-a", "2", false), pod("node-b", "2", true)},
+			nodes:               online("node-a", "node-b"),
+			regroup:             map[string]*corev1.Node{"node-b": {}},
+			maxUnavailable:      1,
+			expectedUpdated:     1,
+			expectedUnavailable: []string{"node-a"},
+		},
+		{
+			name:            "replace-orphaned-pod",
+			pods:            []corev1.Pod{pod("node-a", "2", true), orphaned},
+			nodes:           online("node-a", "node-b"),
+			maxUnavailable:  1,
+			expectedUpdated: 1,
+			expectedReplace: []string{"satellite-node-b"},
+		},
+		{
+			name:                "replace-not-ready-outdated-pod",
+			pods:                []corev1.Pod{pod("node-a", "1", true), pod("node-b", "1", false)},
+			nodes:               online("node-a", "node-b"),
+			maxUnavailable:      1,
+			expectedUnavailable: []string{"node-b"},
+			expectedReplace:     []string{"satellite-node-b"},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			plan := planSatelliteRollout(daemonSets, tcase.pods, tcase.nodes, tcase.resources, tcase.regroup, tcase.maxUnavailable)
+
+			var replace []string
+			for _, pod := range plan.replace {
+				replace = append(replace, pod.Name)
+			}
+
+			if plan.updated != tcase.expectedUpdated {
+				t.Errorf("updated: expected: %d, actual: %d", tcase.expectedUpdated, plan.updated)
+			}
+
+			if !reflect.DeepEqual(tcase.expectedUnavailable, plan.unavailable) {
+				t.Errorf("unavailable: expected: %v, actual: %v", tcase.expectedUnavailable, plan.unavailable)
+			}
+
+			if !reflect.DeepEqual(tcase.expectedReplace, replace) {
+				t.Errorf("replace: expected: %v, actual: %v", tcase.expectedReplace, replace)
+			}
+
+			if !reflect.DeepEqual(tcase.expectedBlocking, plan.blocking) {
+				t.Errorf("blocking: expected: %v, actual: %v", tcase.expectedBlocking, plan.blocking)
+			}
+		})
+	}
+}
+
+func TestRolloutSatellites(t *testing.T) {
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns", Namespace: "piraeus"},
+		Spec:       piraeusv1.LinstorSatelliteSetSpec{NodeSelector: map[string]string{"disk": "ssd"}},
+	}
+
+	ssd := map[string]string{"disk": "ssd"}
+	other := map[string]string{kubeSpec.LinstorSatelliteSetProperty: "piraeus/other"}
+
+	nodes := []lapi.Node{
+		{Name: "owned", Type: lc.Satellite},
+		{Name: "other-set", Type: lc.Satellite, Props: other},
+		{Name: "not-selected", Type: lc.Satellite},
+		{Name: "dangling", Type: lc.Satellite},
+		{Name: "controller", Type: "CONTROLLER"},
+	}
+
+	k8sNodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "owned", Labels: ssd}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-set", Labels: ssd}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "not-selected"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "controller", Labels: ssd}},
+	}
+
+	var actual []string
+	for _, node := range rolloutSatellites(satelliteSet, nodes, k8sNodes) {
+		actual = append(actual, node.Name)
+	}
+
+	expected := []string{"owned"}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected: %v, actual: %v", expected, actual)
+	}
+}
+
+func TestPodOutdated(t *testing.T) {
+	created := metav1.NewTime(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+	recreated := metav1.NewTime(created.Add(time.Hour))
+
+	ds := &apps.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: recreated,
+			Annotations:       map[string]string{apps.DeprecatedTemplateGeneration: "1"},
+		},
+	}
+
+	pod := func(timestamp metav1.Time, generation string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				CreationTimestamp: timestamp,
+				Labels:            map[string]string{podTemplateGenerationLabel: generation},
+			},
+		}
+	}
+
+	if podOutdated(ds, pod(recreated, "1")) {
+		t.Errorf("expected pod of current generation to be up to date")
+	}
+
+	if !podOutdated(ds, pod(recreated, "0")) {
+		t.Errorf("expected pod of previous generation to be outdated")
+	}
+
+	if !podOutdated(ds, pod(created, "1")) {
+		t.Errorf("expected pod created before the daemonset to be outdated")
+	}
+}
+
+func TestDrbdResourceSettled(t *testing.T) {
+	disconnected := drbdReplica("node-a", "UpToDate", true)
+	disconnected.LayerObject.Drbd.Connections = map[string]lapi.DrbdConnection{
+		"node-b": {Connected: false, Message: "Connecting"},
+	}
+
+	testcases := []struct {
+		name     string
+		replica  lapi.ResourceWithVolumes
+		expected bool
+	}{
+		{name: "up-to-date", replica: drbdReplica("node-a", "UpToDate", true), expected: true},
+		{name: "diskless", replica: drbdReplica("node-a", "Diskless", false), expected: true},
+		{name: "inconsistent", replica: drbdReplica("node-a", "Inconsistent", true), expected: false},
+		{name: "outdated-diskless", replica: drbdReplica("node-a", "DUnknown", false), expected: false},
+		{name: "disconnected", replica: disconnected, expected: false},
+		{name: "no-drbd", replica: lapi.ResourceWithVolumes{Volumes: []lapi.Volume{{State: lapi.VolumeState{DiskState: "Outdated"}}}}, expected: true},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := drbdResourceSettled(&tcase.replica)
+			if actual != tcase.expected {
+				t.Errorf("expected: %t, actual: %t", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func drbdReplica(node, diskState string, diskful bool) lapi.ResourceWithVolumes {
+	res := lapi.ResourceWithVolumes{
+		Resource: lapi.Resource{
+			Name:     "res1",
+			NodeName: node,
+			LayerObject: lapi.ResourceLayer{
+				Type: devicelayerkind.Drbd,
+				Drbd: lapi.DrbdResource{
+					Connections: map[string]lapi.DrbdConnection{"other": {Connected: true, Message: "Connected"}},
+				},
+			},
+		},
+		Volumes: []lapi.Volume{{State: lapi.VolumeState{DiskState: diskState}}},
+	}
+
+	if !diskful {
+		res.Flags = []string{"DISKLESS"}
+	}
+
+	return res
+}
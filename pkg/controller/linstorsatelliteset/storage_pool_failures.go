@@ -0,0 +1,276 @@
+/*
+Piraeus Operator
+Copyright 2019 LINBIT USA, LLC.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package linstorsatelliteset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	linstor "github.com/LINBIT/golinstor"
+	lapi "github.com/LINBIT/golinstor/client"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	"github.com/piraeusdatastore/piraeus-operator/pkg/k8s/reconcileutil"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+// A failed storage pool created from devices is replaced once new, empty devices listed in its `replaceDevices` are
+// available:
+//
+// 1. The pool is marked for replacement in LINSTOR, so the replacement continues even if the pool no longer reports a
+//    failure once its replicas are removed. All replicas in the pool are converted to diskless, if another node has an
+//    UpToDate copy of the data.
+// 2. The pool is removed from the node by a node action, wiping all its devices, and deleted in LINSTOR.
+// 3. The pool is recreated from its devices, like any new pool.
+// 4. The replicas are converted back to diskful, DRBD syncs the data from the other replicas.
+
+// failedDiskStates are the DRBD disk states of diskful volumes that lost their backing device.
+var failedDiskStates = sets.NewString("Failed", "Diskless")
+
+// storagePoolFailure returns the failure of a storage pool created from devices, or nil if the pool is healthy.
+//
+// A pool has failed if LINSTOR reports errors for the pool itself, or if any diskful replica in the pool lost its
+// backing device. Pools marked for replacement are reported as failed until they are recreated.
+func storagePoolFailure(pool *lapi.StoragePool, devicePaths []string, nodeResources []lapi.ResourceWithVolumes) *shared.StoragePoolFailureStatus {
+	var reasons []string
+
+	if pool.Props[kubeSpec.LinstorReplacementPendingProperty] != "" {
+		reasons = append(reasons, "pool is being replaced")
+	}
+
+	for _, report := range pool.Reports {
+		if report.Is(linstor.MaskError) {
+			reasons = append(reasons, report.Message)
+		}
+	}
+
+	var failed []string
+
+	for i := range nodeResources {
+		res := &nodeResources[i]
+		if !lc.IsDiskful(res) {
+			continue
+		}
+
+		for j := range res.Volumes {
+			if res.Volumes[j].StoragePoolName == pool.StoragePoolName && failedDiskStates.Has(res.Volumes[j].State.DiskState) {
+				failed = append(failed, res.Name)
+				break
+			}
+		}
+	}
+
+	if len(reasons) == 0 && len(failed) == 0 {
+		return nil
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "replicas lost their backing device")
+	}
+
+	sort.Strings(failed)
+
+	return &shared.StoragePoolFailureStatus{
+		Reason:          strings.Join(reasons, "; "),
+		DevicePaths:     devicePaths,
+		FailedResources: failed,
+	}
+}
+
+// reportStoragePoolFailures adds the failure status to all storage pools created from devices that failed.
+func reportStoragePoolFailures(status *shared.SatelliteStatus, satelliteSet *piraeusv1.LinstorSatelliteSet, pools []lapi.StoragePool, resources []lapi.ResourceWithVolumes) {
+	if satelliteSet.Spec.StoragePools == nil {
+		return
+	}
+
+	var nodeResources []lapi.ResourceWithVolumes
+
+	for i := range resources {
+		if resources[i].NodeName == status.NodeName {
+			nodeResources = append(nodeResources, resources[i])
+		}
+	}
+
+	devicePaths := make(map[string][]string)
+
+	for _, pool := range satelliteSet.Spec.StoragePools.AllPhysicalStorageCreators() {
+		if len(pool.GetDevicePaths()) != 0 {
+			devicePaths[pool.GetName()] = pool.GetDevicePaths()
+		}
+	}
+
+	for i := range pools {
+		paths, ok := devicePaths[pools[i].StoragePoolName]
+		if !ok || pools[i].Props[kubeSpec.LinstorRegistrationProperty] != kubeSpec.Name {
+			continue
+		}
+
+		failure := storagePoolFailure(&pools[i], paths, nodeResources)
+		if failure == nil {
+			continue
+		}
+
+		for _, poolStatus := range status.StoragePoolStatuses {
+			if poolStatus.Name == pools[i].StoragePoolName {
+				poolStatus.Failure = failure
+			}
+		}
+	}
+}
+
+// storagePoolFailures returns the reported failures by storage pool name.
+func storagePoolFailures(status *shared.SatelliteStatus) map[string]*shared.StoragePoolFailureStatus {
+	result := make(map[string]*shared.StoragePoolFailureStatus)
+
+	for _, poolStatus := range status.StoragePoolStatuses {
+		if poolStatus.Failure != nil {
+			result[poolStatus.Name] = poolStatus.Failure
+		}
+	}
+
+	return result
+}
+
+// recordStoragePoolFailureEvents emits an event for every storage pool that failed since the last report, or whose
+// failure changed.
+func recordStoragePoolFailureEvents(recorder record.EventRecorder, satelliteSet *piraeusv1.LinstorSatelliteSet, previous map[string]*shared.StoragePoolFailureStatus, current *shared.SatelliteStatus) {
+	for name, failure := range storagePoolFailures(current) {
+		if reflect.DeepEqual(previous[name], failure) {
+			continue
+		}
+
+		recorder.Eventf(satelliteSet, corev1.EventTypeWarning, "StoragePoolFailed", "Storage pool '%s' on node '%s' failed: %s. Affected resources: %v. Replace the failed device in devicePaths %v and list the new device in replaceDevices to rebuild the pool", name, current.NodeName, failure.Reason, failure.FailedResources, failure.DevicePaths)
+	}
+}
+
+// replaceFailedStoragePool rebuilds a failed storage pool on the node from its devices. Returns a TemporaryError until
+// the pool is removed and can be recreated.
+func (r *ReconcileLinstorSatelliteSet) replaceFailedStoragePool(ctx context.Context, linstorClient *lc.HighLevelClient, satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName string, existing *lapi.StoragePool, pool shared.StoragePoolRebuilder) error {
+	logger := log.WithFields(logrus.Fields{
+		"Name":      satelliteSet.Name,
+		"Namespace": satelliteSet.Namespace,
+		"Node":      nodeName,
+		"Pool":      pool.GetName(),
+		"Op":        "replaceFailedStoragePool",
+	})
+
+	if existing.Props[kubeSpec.LinstorReplacementPendingProperty] == "" {
+		err := linstorClient.ModifyStoragePoolProps(ctx, nodeName, pool.GetName(), lapi.GenericPropsModify{
+			OverrideProps: map[string]string{kubeSpec.LinstorReplacementPendingProperty: "true"},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark pool '%s' for replacement: %w", pool.GetName(), err)
+		}
+	}
+
+	progress, err := linstorClient.DetachFromStoragePool(ctx, nodeName, pool.GetName())
+	if err != nil {
+		return fmt.Errorf("failed to detach replicas from pool '%s': %w", pool.GetName(), err)
+	}
+
+	if len(progress.Blocked) != 0 {
+		return fmt.Errorf("can't replace storage pool '%s' on node '%s': resources %v have no UpToDate replica on another node", pool.GetName(), nodeName, progress.Blocked)
+	}
+
+	if !progress.Done() {
+		logger.WithField("remaining", progress.Remaining).Info("converted replicas in failed storage pool to diskless")
+
+		return &reconcileutil.TemporaryError{
+			Source:       fmt.Errorf("waiting for replicas %v to be removed from storage pool '%s' on node '%s'", progress.Remaining, pool.GetName(), nodeName),
+			RequeueAfter: connectionRetrySeconds * time.Second,
+		}
+	}
+
+	logger.Info("removing failed storage pool from node")
+
+	err = r.runNodeAction(ctx, satelliteSet, nodeName, "pool-destroy", pool.DestroyCommand())
+	if err != nil {
+		return err
+	}
+
+	err = linstorClient.Nodes.DeleteStoragePool(ctx, nodeName, pool.GetName())
+	if err != nil && err != lapi.NotFoundError {
+		return fmt.Errorf("failed to delete failed pool '%s': %w", pool.GetName(), err)
+	}
+
+	r.recorder.Eventf(satelliteSet, corev1.EventTypeNormal, "StoragePoolRebuilding", "Removed failed storage pool '%s' on node '%s', recreating it from devices %v", pool.GetName(), nodeName, pool.GetDevicePaths())
+
+	return &reconcileutil.TemporaryError{
+		Source:       fmt.Errorf("waiting for devices of storage pool '%s' on node '%s' to be available", pool.GetName(), nodeName),
+		RequeueAfter: connectionRetrySeconds * time.Second,
+	}
+}
+
+// reattachReplacedReplicas converts the replicas detached from a failed storage pool back to diskful, once the pool
+// was recreated.
+func (r *ReconcileLinstorSatelliteSet) reattachReplacedReplicas(ctx context.Context, linstorClient *lc.HighLevelClient, satelliteSet *piraeusv1.LinstorSatelliteSet, nodeName string) error {
+	if satelliteSet.Spec.StoragePools == nil {
+		return nil
+	}
+
+	currentPools, err := linstorClient.Nodes.GetStoragePools(ctx, nodeName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch storage pools: %w", err)
+	}
+
+	wanted := sets.NewString()
+
+	for _, pool := range satelliteSet.Spec.StoragePools.AllPhysicalStorageCreators() {
+		if len(pool.GetDevicePaths()) != 0 {
+			wanted.Insert(pool.GetName())
+		}
+	}
+
+	var healthy []string
+
+	for i := range currentPools {
+		// Replicas are only restored to the recreated pool, not the failed pool that is still being replaced.
+		if currentPools[i].Props[kubeSpec.LinstorReplacementPendingProperty] != "" {
+			continue
+		}
+
+		if wanted.Has(currentPools[i].StoragePoolName) && storagePoolFailure(&currentPools[i], nil, nil) == nil {
+			healthy = append(healthy, currentPools[i].StoragePoolName)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	reattached, err := linstorClient.ReattachReplicas(ctx, nodeName, healthy...)
+	if err != nil {
+		return err
+	}
+
+	if len(reattached) != 0 {
+		r.recorder.Eventf(satelliteSet, corev1.EventTypeNormal, "StoragePoolReplicasRestored", "Restored replicas of resources %v on node '%s', syncing from peers", reattached, nodeName)
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package linstorsatelliteset
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	linstor "github.com/LINBIT/golinstor"
+	lapi "github.com/LINBIT/golinstor/client"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis"
+	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	piraeusv1 "github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/v1"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+	lc "github.com/piraeusdatastore/piraeus-operator/pkg/linstor/client"
+)
+
+func TestStoragePoolFailure(t *testing.T) {
+	replica := func(name, pool, diskState string, flags ...string) lapi.ResourceWithVolumes {
+		return lapi.ResourceWithVolumes{
+			Resource: lapi.Resource{Name: name, NodeName: "node-1", Flags: flags},
+			Volumes: []lapi.Volume{
+				{StoragePoolName: pool, State: lapi.VolumeState{DiskState: diskState}},
+			},
+		}
+	}
+
+	retCode := func(mask uint64) int64 {
+		return int64(mask)
+	}
+
+	devicePaths := []string{"/dev/vdb"}
+
+	testcases := []struct {
+		name      string
+		pool      lapi.StoragePool
+		resources []lapi.ResourceWithVolumes
+		expected  *shared.StoragePoolFailureStatus
+	}{
+		{
+			name: "healthy",
+			pool: lapi.StoragePool{StoragePoolName: "lvm"},
+			resources: []lapi.ResourceWithVolumes{
+				replica("res1", "lvm", "UpToDate"),
+				replica("res2", "DfltDisklessStorPool", "Diskless", "DISKLESS"),
+			},
+		},
+		{
+			name: "failed-replicas",
+			pool: lapi.StoragePool{StoragePoolName: "lvm"},
+			resources: []lapi.ResourceWithVolumes{
+				replica("res2", "lvm", "Diskless"),
+				replica("res1", "lvm", "Failed"),
+				replica("res3", "other", "Failed"),
+			},
+			expected: &shared.StoragePoolFailureStatus{
+				Reason:          "replicas lost their backing device",
+				DevicePaths:     devicePaths,
+				FailedResources: []string{"res1", "res2"},
+			},
+		},
+		{
+			name: "pool-errors",
+			pool: lapi.StoragePool{
+				StoragePoolName: "lvm",
+				Reports: []lapi.ApiCallRc{
+					{RetCode: retCode(linstor.MaskWarn), Message: "just a warning"},
+					{RetCode: retCode(linstor.MaskError), Message: "Volume group 'vg' not found"},
+				},
+			},
+			expected: &shared.StoragePoolFailureStatus{
+				Reason:      "Volume group 'vg' not found",
+				DevicePaths: devicePaths,
+			},
+		},
+		{
+			name: "replacement-pending",
+			pool: lapi.StoragePool{
+				StoragePoolName: "lvm",
+				Props:           map[string]string{kubeSpec.LinstorReplacementPendingProperty: "true"},
+			},
+			expected: &shared.StoragePoolFailureStatus{
+				Reason:      "pool is being replaced",
+				DevicePaths: devicePaths,
+			},
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			actual := storagePoolFailure(&tcase.pool, devicePaths, tcase.resources)
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %+v, actual: %+v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRecordStoragePoolFailureEvents(t *testing.T) {
+	satelliteSet := &piraeusv1.LinstorSatelliteSet{}
+	failure := &shared.StoragePoolFailureStatus{Reason: "Volume group 'vg' not found", DevicePaths: []string{"/dev/vdb"}}
+
+	current := &shared.SatelliteStatus{
+		NodeStatus: shared.NodeStatus{NodeName: "node-1"},
+		StoragePoolStatuses: []*shared.StoragePoolStatus{
+			{Name: "lvm", Failure: failure},
+			{Name: "healthy"},
+		},
+	}
+
+	recorder := record.NewFakeRecorder(10)
+	recordStoragePoolFailureEvents(recorder, satelliteSet, nil, current)
+
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(recorder.Events))
+	}
+
+	event := <-recorder.Events
+	if !strings.Contains(event, "StoragePoolFailed") || !strings.Contains(event, "'lvm'") {
+		t.Errorf("unexpected event: %s", event)
+	}
+
+	recordStoragePoolFailureEvents(recorder, satelliteSet, map[string]*shared.StoragePoolFailureStatus{"lvm": failure.DeepCopy()}, current)
+
+	if len(recorder.Events) != 0 {
+		t.Errorf("expected no event for unchanged failure, got %d", len(recorder.Events))
+	}
+}
+
+func TestFailedStoragePoolWithNewDevice(t *testing.T) {
+	err := apis.AddToScheme(scheme.Scheme)
+	if err != nil {
+		t.Fatalf("failed to add scheme: %v", err)
+	}
+
+	resources := []lapi.ResourceWithVolumes{
+		{
+			Resource: lapi.Resource{Name: "res1", NodeName: "node-1"},
+			Volumes:  []lapi.Volume{{StoragePoolName: "lvm", State: lapi.VolumeState{DiskState: "Failed"}}},
+		},
+		{
+			Resource: lapi.Resource{Name: "res1", NodeName: "node-2"},
+			Volumes:  []lapi.Volume{{StoragePoolName: "lvm", State: lapi.VolumeState{DiskState: lc.DiskStateUpToDate}}},
+		},
+	}
+
+	testcases := []struct {
+		name           string
+		replaceDevices []string
+		expectedAction string
+		expectReplace  bool
+	}{
+		{
+			name:           "extend",
+			expectedAction: "vg-extend",
+		},
+		{
+			name:           "replace",
+			replaceDevices: []string{"/dev/vdc"},
+			expectReplace:  true,
+		},
+	}
+
+	for i := range testcases {
+		tcase := &testcases[i]
+		t.Run(tcase.name, func(t *testing.T) {
+			var requests []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/physical-storage/":
+					_ = json.NewEncoder(w).Encode([]lapi.PhysicalStorage{
+						{Size: 10 << 30, Nodes: map[string][]lapi.PhysicalStorageDevice{"node-1": {{Device: "/dev/vdc"}}}},
+					})
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/nodes/node-1/storage-pools":
+					_ = json.NewEncoder(w).Encode([]lapi.StoragePool{
+						{StoragePoolName: "lvm", NodeName: "node-1", ProviderKind: lapi.LVM, Props: map[string]string{kubeSpec.LinstorRegistrationProperty: kubeSpec.Name}},
+					})
+				case r.Method == http.MethodGet && r.URL.Path == "/v1/view/resources":
+					_ = json.NewEncoder(w).Encode(resources)
+				default:
+					requests = append(requests, r.Method+" "+r.URL.Path)
+					_, _ = w.Write([]byte("[]"))
+				}
+			}))
+			defer server.Close()
+
+			linstorClient, err := lc.NewHighLevelLinstorClientFromConfig(server.URL, &shared.LinstorClientConfig{}, nil)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			satelliteSet := &piraeusv1.LinstorSatelliteSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "piraeus-ns", Namespace: "piraeus", UID: "1234"},
+				Spec: piraeusv1.LinstorSatelliteSetSpec{
+					StoragePools: &shared.StoragePools{
+						LVMPools: []*shared.StoragePoolLVM{
+							{
+								CommonStoragePoolOptions: shared.CommonStoragePoolOptions{Name: "lvm"},
+								CommonPhysicalStorageOptions: shared.CommonPhysicalStorageOptions{
+									DevicePaths:    []string{"/dev/vdb", "/dev/vdc"},
+									ReplaceDevices: tcase.replaceDevices,
+								},
+								VolumeGroup: "vg",
+							},
+						},
+					},
+				},
+			}
+
+			kubeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			r := &ReconcileLinstorSatelliteSet{client: kubeClient, scheme: scheme.Scheme, recorder: record.NewFakeRecorder(10)}
+			pod := &corev1.Pod{Spec: corev1.PodSpec{NodeName: "node-1"}}
+
+			err = r.reconcileAutomaticDeviceSetup(context.Background(), linstorClient, satelliteSet, pod)
+			if err == nil {
+				t.Fatalf("expected to wait for the pool to be changed")
+			}
+
+			jobs := &batchv1.JobList{}
+
+			err = kubeClient.List(context.Background(), jobs)
+			if err != nil {
+				t.Fatalf("failed to list jobs: %v", err)
+			}
+
+			var actions []string
+			for i := range jobs.Items {
+				actions = append(actions, jobs.Items[i].Labels[kubeSpec.NodeActionLabel])
+			}
+
+			if tcase.expectedAction != "" && !reflect.DeepEqual(actions, []string{tcase.expectedAction}) {
+				t.Errorf("expected node action '%s', got %v", tcase.expectedAction, actions)
+			}
+
+			markedForReplacement := len(requests) != 0 && requests[0] == "PUT /v1/nodes/node-1/storage-pools/lvm"
+			if markedForReplacement != tcase.expectReplace {
+				t.Errorf("expected replacement: %t, got requests %v", tcase.expectReplace, requests)
+			}
+		})
+	}
+}
@@ -42,7 +42,16 @@ var OnPatchErrorReturn OnPatchError
 
 // OnPatchErrorRecreate recreates a resource by deleting old resources before applying it again.
 func OnPatchErrorRecreate(ctx context.Context, kubeClient client.Client, current, desired GCRuntimeObject) error {
-	policy := metav1.DeletePropagationForeground
+	return recreate(ctx, kubeClient, current, desired, metav1.DeletePropagationForeground)
+}
+
+// OnPatchErrorRecreateOrphan recreates a resource by deleting it before applying it again. Dependent resources, such
+// as the pods of a DaemonSet, are orphaned instead of deleted, so they keep running until they are replaced.
+func OnPatchErrorRecreateOrphan(ctx context.Context, kubeClient client.Client, current, desired GCRuntimeObject) error {
+	return recreate(ctx, kubeClient, current, desired, metav1.DeletePropagationOrphan)
+}
+
+func recreate(ctx context.Context, kubeClient client.Client, current, desired GCRuntimeObject, policy metav1.DeletionPropagation) error {
 	resourceVersion := current.GetResourceVersion()
 	uid := current.GetUID()
 	deleteOptions := &client.DeleteOptions{
@@ -23,6 +23,10 @@ import corev1 "k8s.io/api/core/v1"
 const (
 	DevDir                      = "/dev/"
 	DevDirName                  = "device-dir"
+	FileStoragePoolDirName      = "file-pool-dir"
+	HostEtcDir                  = "/etc"
+	HostEtcDirName              = "host-etc"
+	HostEtcMountPath            = "/host/etc"
 	LinstorConfDir              = "/etc/linstor"
 	LinstorCertDir              = "/etc/linstor/certs"
 	LinstorClientDir            = "/etc/linstor/client"
@@ -31,6 +35,8 @@ const (
 	LinstorHttpsCertDirName     = "linstor-https"
 	LinstorHttpsCertPassword    = "linstor"
 	LinstorSslDir               = "/etc/linstor/ssl"
+	LinstorSslDefaultPassword   = "linstor"
+	LinstorSslDefaultProtocol   = "TLSv1.2"
 	LinstorConfDirName          = "linstor-conf"
 	LinstorCertDirName          = "linstor-certs"
 	LinstorSslDirName           = "linstor-ssl"
@@ -47,6 +53,8 @@ const (
 	DrbdPrometheuscConfName     = "drbd-reactor-config"
 	MonitorungPortNumber        = 9942
 	MonitoringPortName          = "prometheus"
+	MonitoringProxyTLSDir       = "/etc/kube-rbac-proxy/tls/"
+	MonitoringProxyTLSDirName   = "monitoring-tls"
 )
 
 // Special strings for communicating with the module injector
@@ -61,9 +69,54 @@ const (
 
 // Special strings when configuring Linstor
 const (
-	LinstorLUKSPassphraseEnvName = "MASTER_PASSPHRASE"
-	JavaOptsName                 = "JAVA_OPTS"
-	LinstorRegistrationProperty  = "Aux/registered-by"
+	LinstorLUKSPassphraseEnvName      = "MASTER_PASSPHRASE"
+	JavaOptsName                      = "JAVA_OPTS"
+	LinstorRegistrationProperty       = "Aux/registered-by"
+	LinstorSatelliteSetProperty       = "Aux/registered-by-satellite-set"
+	LinstorManagedPropertiesProperty  = "Aux/registered-properties"
+	LinstorManagedInterfacesProperty  = "Aux/registered-interfaces"
+	LinstorEvacuationProperty         = "Aux/evacuation-replicas"
+	LinstorReplacedPoolProperty       = "Aux/replaced-storage-pool"
+	LinstorReplacementPendingProperty = "Aux/replacement-pending"
+	LinstorAutoplaceTargetProperty    = "AutoplaceTarget"
+	LinstorPrefNicProperty            = "PrefNic"
+	LinstorDefaultNetInterface        = "default"
+)
+
+// Keys of the secret holding the passwords of the java keystores used for TLS between controller and satellites
+const (
+	LinstorSslKeystorePasswordKey   = "keystorePassword"
+	LinstorSslKeyPasswordKey        = "keyPassword"
+	LinstorSslTruststorePasswordKey = "truststorePassword"
+)
+
+// Controller properties configuring the TLS connections to satellites. LINSTOR reads them on startup.
+const (
+	LinstorSslConnectorKeystorePasswordProperty   = "netcom/SslConnector/keyStorePasswd"
+	LinstorSslConnectorKeyPasswordProperty        = "netcom/SslConnector/keyPasswd"
+	LinstorSslConnectorTruststorePasswordProperty = "netcom/SslConnector/trustStorePasswd"
+	LinstorSslConnectorProtocolProperty           = "netcom/SslConnector/sslProtocol"
+)
+
+// Labels added to resources created by the operator
+const (
+	NodeActionLabel = APIGroup + "/node-action"
+	// Name of the node a LinstorPhysicalDevice is attached to
+	PhysicalDeviceNodeLabel = APIGroup + "/node"
+)
+
+// Annotations added to kubernetes nodes during maintenance
+const (
+	CordonedByMaintenanceAnnotation          = APIGroup + "/cordoned-by-maintenance"
+	AutoplaceDisabledByMaintenanceAnnotation = APIGroup + "/autoplace-disabled-by-maintenance"
+)
+
+// Labels added to kubernetes nodes running satellites
+const (
+	// Name of the kernel module injection image entry matching the node
+	KernelModuleInjectionGroupLabel = APIGroup + "/kernel-module-injection-group"
+	// Name of the satellite node override matching the node
+	SatelliteNodeOverrideLabel = APIGroup + "/satellite-node-override"
 )
 
 // k8s constants: Special names for k8s APIs.
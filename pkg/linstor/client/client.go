@@ -1,17 +1,21 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
-	linstor "github.com/LINBIT/golinstor"
 	lapi "github.com/LINBIT/golinstor/client"
 	"github.com/sirupsen/logrus"
 	ini "gopkg.in/ini.v1"
@@ -60,6 +64,10 @@ type StorageNode struct {
 // HighLevelClient is a golinstor client with convience functions.
 type HighLevelClient struct {
 	lapi.Client
+
+	// Used for requests that are not (correctly) implemented by golinstor.
+	baseURL    *url.URL
+	httpClient *http.Client
 }
 
 type SecretFetcher func(string) (map[string][]byte, error)
@@ -95,6 +103,8 @@ func NewHighLevelLinstorClientFromConfig(endpoint string, config *shared.Linstor
 		return nil, fmt.Errorf("unable to create LINSTOR API client: %v", err)
 	}
 
+	httpClient := &http.Client{Transport: &transport}
+
 	c, err := NewHighLevelClient(
 		lapi.BaseURL(u),
 		lapi.Log(&logrus.Logger{
@@ -102,12 +112,15 @@ func NewHighLevelLinstorClientFromConfig(endpoint string, config *shared.Linstor
 			Out:       os.Stdout,
 			Formatter: &logrus.TextFormatter{},
 		}),
-		lapi.HTTPClient(&http.Client{Transport: &transport}),
+		lapi.HTTPClient(httpClient),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("unable to create LINSTOR API client: %v", err)
 	}
 
+	c.baseURL = u
+	c.httpClient = httpClient
+
 	return c, nil
 }
 
@@ -165,11 +178,17 @@ func NewHighLevelClient(options ...lapi.Option) (*HighLevelClient, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &HighLevelClient{*c}, nil
+	return &HighLevelClient{Client: *c}, nil
 }
 
 // GetNodeOrCreate gets a linstor node, creating it if it is not already present.
+//
+// The properties of the node are updated to match the given ones. Of the properties not given, only those recorded
+// in the managed properties marker of the existing node are removed, so that properties set by other means are kept.
+// The same applies to network interfaces: only interfaces previously created by this function are removed.
 func (c *HighLevelClient) GetNodeOrCreate(ctx context.Context, node lapi.Node) (*lapi.Node, error) {
+	node.Props = withManagedInterfaces(node.Props, node.NetInterfaces)
+
 	existingNode, err := c.Nodes.Get(ctx, node.Name)
 	if err != nil {
 		// For 404
@@ -201,19 +220,7 @@ func (c *HighLevelClient) GetNodeOrCreate(ctx context.Context, node lapi.Node) (
 		}
 	}
 
-	var propsToDelete []string
-
-	for k := range existingNode.Props {
-		if !strings.HasPrefix(k, linstor.NamespcAuxiliary+"/") {
-			// We only want to manage auxiliary properties
-			continue
-		}
-
-		_, ok := node.Props[k]
-		if !ok {
-			propsToDelete = append(propsToDelete, k)
-		}
-	}
+	propsToDelete := unwantedProps(existingNode.Props, node.Props)
 
 	if !upToDate || len(propsToDelete) != 0 {
 		err := c.Nodes.Modify(ctx, node.Name, lapi.NodeModify{GenericPropsModify: lapi.GenericPropsModify{OverrideProps: node.Props, DeleteProps: propsToDelete}})
@@ -229,16 +236,100 @@ func (c *HighLevelClient) GetNodeOrCreate(ctx context.Context, node lapi.Node) (
 		}
 	}
 
+	for _, name := range unwantedInterfaces(existingNode, node.NetInterfaces) {
+		err = c.Nodes.DeleteNetinterface(ctx, node.Name, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove network interface '%s': %w", name, err)
+		}
+	}
+
 	return &existingNode, nil
 }
 
+// withManagedInterfaces returns the properties with the marker recording the names of the network interfaces
+// created by the operator.
+func withManagedInterfaces(props map[string]string, netInterfaces []lapi.NetInterface) map[string]string {
+	result := make(map[string]string, len(props)+1)
+	for k, v := range props {
+		result[k] = v
+	}
+
+	names := make([]string, 0, len(netInterfaces))
+	for i := range netInterfaces {
+		names = append(names, netInterfaces[i].Name)
+	}
+
+	sort.Strings(names)
+
+	result[kubeSpec.LinstorManagedInterfacesProperty] = strings.Join(names, ",")
+
+	return result
+}
+
+// unwantedInterfaces returns the network interfaces of the node recorded in the managed interfaces marker, that are
+// no longer wanted. Interfaces created by other means are never returned.
+func unwantedInterfaces(existing lapi.Node, wanted []lapi.NetInterface) []string {
+	val := existing.Props[kubeSpec.LinstorManagedInterfacesProperty]
+	if val == "" {
+		return nil
+	}
+
+	managed := make(map[string]struct{})
+	for _, name := range strings.Split(val, ",") {
+		managed[name] = struct{}{}
+	}
+
+	for i := range wanted {
+		delete(managed, wanted[i].Name)
+	}
+
+	var result []string
+
+	for i := range existing.NetInterfaces {
+		if _, ok := managed[existing.NetInterfaces[i].Name]; ok {
+			result = append(result, existing.NetInterfaces[i].Name)
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+// unwantedProps returns the properties recorded in the managed properties marker, that are no longer wanted. The
+// marker itself is removed once no longer wanted.
+func unwantedProps(existing, wanted map[string]string) []string {
+	var result []string
+
+	for _, k := range shared.ManagedPropertyKeys(existing) {
+		if _, ok := existing[k]; !ok {
+			continue
+		}
+
+		if _, ok := wanted[k]; !ok {
+			result = append(result, k)
+		}
+	}
+
+	_, markerExists := existing[kubeSpec.LinstorManagedPropertiesProperty]
+	_, markerWanted := wanted[kubeSpec.LinstorManagedPropertiesProperty]
+
+	if markerExists && !markerWanted {
+		result = append(result, kubeSpec.LinstorManagedPropertiesProperty)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
 func (c *HighLevelClient) ensureWantedInterface(ctx context.Context, node lapi.Node, wanted lapi.NetInterface) error {
 	for _, nodeIf := range node.NetInterfaces {
 		if nodeIf.Name != wanted.Name {
 			continue
 		}
 
-		if nodeIf.Address == wanted.Address {
+		if netInterfaceUpToDate(nodeIf, wanted) {
 			return nil
 		}
 
@@ -249,6 +340,125 @@ func (c *HighLevelClient) ensureWantedInterface(ctx context.Context, node lapi.N
 	return c.Nodes.CreateNetInterface(ctx, node.Name, wanted)
 }
 
+// netInterfaceUpToDate returns true if the existing interface matches the wanted one. Addresses are compared as IPs,
+// as LINSTOR may report IPv6 addresses in a different notation. Port and encryption type are only compared for
+// interfaces used for the controller connection.
+func netInterfaceUpToDate(existing, wanted lapi.NetInterface) bool {
+	existingIP := net.ParseIP(existing.Address)
+	wantedIP := net.ParseIP(wanted.Address)
+
+	if existingIP == nil || wantedIP == nil {
+		if existing.Address != wanted.Address {
+			return false
+		}
+	} else if !existingIP.Equal(wantedIP) {
+		return false
+	}
+
+	if !wanted.IsActive {
+		return true
+	}
+
+	return existing.SatellitePort == wanted.SatellitePort && existing.SatelliteEncryptionType == wanted.SatelliteEncryptionType
+}
+
+// PodAddresses returns all addresses of the pod, starting with the primary address.
+func PodAddresses(pod *corev1.Pod) []string {
+	var result []string
+
+	if pod.Status.PodIP != "" {
+		result = append(result, pod.Status.PodIP)
+	}
+
+	for _, podIP := range pod.Status.PodIPs {
+		if podIP.IP != pod.Status.PodIP {
+			result = append(result, podIP.IP)
+		}
+	}
+
+	return result
+}
+
+// DefaultNetInterfaces returns the network interfaces to register for a node reachable on the given addresses.
+//
+// The address of the first requested family is registered as the active "default" interface, used for the
+// connection to the controller. Addresses of additional families are registered as inactive interfaces named after
+// the family. If no family is requested, only the first address is registered.
+func DefaultNetInterfaces(addresses []string, families []shared.IPFamily, sslConfig *shared.LinstorSSLConfig) ([]lapi.NetInterface, error) {
+	err := shared.ValidateIPFamilies(families)
+	if err != nil {
+		return nil, err
+	}
+
+	selected, err := shared.FamilyAddresses(addresses, families)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []lapi.NetInterface{
+		{
+			Name:                    kubeSpec.LinstorDefaultNetInterface,
+			Address:                 selected[0],
+			IsActive:                true,
+			SatellitePort:           sslConfig.Port(),
+			SatelliteEncryptionType: sslConfig.Type(),
+		},
+	}
+
+	for i := 1; i < len(selected); i++ {
+		result = append(result, lapi.NetInterface{Name: families[i].NetInterfaceName(), Address: selected[i]})
+	}
+
+	return result, nil
+}
+
+// ModifyStoragePoolProps updates the properties of a storage pool on the given node.
+//
+// golinstor's ModifyStoragePool sends the wrong method and body, so the request is sent directly.
+func (c *HighLevelClient) ModifyStoragePoolProps(ctx context.Context, nodeName, poolName string, modify lapi.GenericPropsModify) error {
+	if c.baseURL == nil || c.httpClient == nil {
+		return fmt.Errorf("client not configured for direct requests")
+	}
+
+	body, err := json.Marshal(modify)
+	if err != nil {
+		return err
+	}
+
+	u := c.baseURL.ResolveReference(&url.URL{Path: "/v1/nodes/" + url.PathEscape(nodeName) + "/storage-pools/" + url.PathEscape(poolName)})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return lapi.NotFoundError
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		var rets lapi.ApiCallError
+
+		err := json.NewDecoder(resp.Body).Decode(&rets)
+		if err != nil {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+
+		return rets
+	}
+
+	return nil
+}
+
 // GetAllResourcesOnNode returns a list of all resources on the specified node.
 func (c *HighLevelClient) GetAllResourcesOnNode(ctx context.Context, nodeName string) ([]lapi.ResourceWithVolumes, error) {
 	resList, err := c.Resources.GetResourceView(ctx) //, &lapi.ListOpts{Node: []string{nodeName}}) : not working
@@ -335,10 +545,12 @@ func NewClientConfigForAPIResource(endpoint string, resource *shared.LinstorClie
 }
 
 func DefaultControllerServiceEndpoint(serviceName types.NamespacedName, useHTTPS bool) string {
+	host := fmt.Sprintf("%s.%s.svc", serviceName.Name, serviceName.Namespace)
+
 	if useHTTPS {
-		return fmt.Sprintf("https://%s.%s.svc:%d", serviceName.Name, serviceName.Namespace, DefaultHTTPSPort)
+		return fmt.Sprintf("https://%s", net.JoinHostPort(host, strconv.Itoa(DefaultHTTPSPort)))
 	} else {
-		return fmt.Sprintf("http://%s.%s.svc:%d", serviceName.Name, serviceName.Namespace, DefaultHTTPPort)
+		return fmt.Sprintf("http://%s", net.JoinHostPort(host, strconv.Itoa(DefaultHTTPPort)))
 	}
 }
 
@@ -18,10 +18,15 @@ limitations under the License.
 package client
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 
 	"github.com/piraeusdatastore/piraeus-operator/pkg/apis/piraeus/shared"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
 
 	corev1 "k8s.io/api/core/v1"
 
@@ -259,3 +264,439 @@ func TestClientConfigAsEnvVars(t *testing.T) {
 		})
 	}
 }
+
+func TestModifyStoragePoolProps(t *testing.T) {
+	var (
+		method string
+		path   string
+		body   lapi.GenericPropsModify
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Errorf("failed to decode request body: %v", err)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	c, err := NewHighLevelLinstorClientFromConfig(server.URL, &shared.LinstorClientConfig{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	expected := lapi.GenericPropsModify{
+		OverrideProps: map[string]string{"PrefNic": "storage"},
+		DeleteProps:   []string{"MaxOversubscriptionRatio"},
+	}
+
+	err = c.ModifyStoragePoolProps(context.Background(), "node1", "pool1", expected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if method != http.MethodPut {
+		t.Errorf("expected method %s, got %s", http.MethodPut, method)
+	}
+
+	if path != "/v1/nodes/node1/storage-pools/pool1" {
+		t.Errorf("unexpected path %s", path)
+	}
+
+	if !reflect.DeepEqual(expected, body) {
+		t.Errorf("expected body %+v, got %+v", expected, body)
+	}
+}
+
+func TestPlanEvacuation(t *testing.T) {
+	replica := func(name, node, pool, diskState string, props map[string]string, flags ...string) lapi.ResourceWithVolumes {
+		return lapi.ResourceWithVolumes{
+			Resource: lapi.Resource{Name: name, NodeName: node, Props: props, Flags: flags},
+			Volumes: []lapi.Volume{
+				{StoragePoolName: pool, State: lapi.VolumeState{DiskState: diskState}},
+			},
+		}
+	}
+
+	marked := map[string]string{kubeSpec.LinstorEvacuationProperty: "2"}
+
+	type expectedStep struct {
+		name         string
+		action       evacuationAction
+		wanted       int
+		recordWanted bool
+		additional   int32
+	}
+
+	tableTest := []struct {
+		name      string
+		resources []lapi.ResourceWithVolumes
+		filter    ReplicaFilter
+		expected  []expectedStep
+	}{
+		{
+			name:      "empty",
+			resources: nil,
+			filter:    AllReplicas,
+			expected:  nil,
+		},
+		{
+			name: "place-new-replica",
+			resources: []lapi.ResourceWithVolumes{
+				replica("res1", "node1", "pool1", DiskStateUpToDate, nil),
+				replica("res1", "node2", "pool1", DiskStateUpToDate, nil),
+			},
+			filter: AllReplicas,
+			expected: []expectedStep{
+				{name: "res1", action: evacuationPlace, wanted: 2, recordWanted: true, additional: 1},
+			},
+		},
+		{
+			name: "wait-for-sync",
+			resources: []lapi.ResourceWithVolumes{
+				replica("res1", "node1", "pool1", DiskStateUpToDate, marked),
+				replica("res1", "node2", "pool1", DiskStateUpToDate, nil),
+				replica("res1", "node3", "pool1", "Inconsistent", nil),
+			},
+			filter: AllReplicas,
+			expected: []expectedStep{
+				{name: "res1", action: evacuationSync, wanted: 2},
+			},
+		},
+		{
+			name: "remove-evacuated",
+			resources: []lapi.ResourceWithVolumes{
+				replica("res1", "node1", "pool1", DiskStateUpToDate, marked),
+				replica("res1", "node2", "pool1", DiskStateUpToDate, nil),
+				replica("res1", "node3", "pool1", DiskStateUpToDate, nil),
+			},
+			filter: AllReplicas,
+			expected: []expectedStep{
+				{name: "res1", action: evacuationRemove, wanted: 2},
+			},
+		},
+		{
+			name: "skip-diskless-and-deleting",
+			resources: []lapi.ResourceWithVolumes{
+				replica("res1", "node1", "pool1", "Diskless", nil, "DISKLESS"),
+				replica("res2", "node1", "pool1", DiskStateUpToDate, nil, "DELETE"),
+				replica("res2", "node2", "pool1", DiskStateUpToDate, nil),
+			},
+			filter:   AllReplicas,
+			expected: nil,
+		},
+		{
+			name: "only-selected-pool",
+			resources: []lapi.ResourceWithVolumes{
+				replica("res2", "node1", "pool2", DiskStateUpToDate, nil),
+				replica("res1", "node1", "pool1", DiskStateUpToDate, nil),
+				replica("res1", "node2", "pool1", DiskStateUpToDate, nil, "DELETE"),
+			},
+			filter: InStoragePool("pool1"),
+			expected: []expectedStep{
+				{name: "res1", action: evacuationPlace, wanted: 1, recordWanted: true, additional: 1},
+			},
+		},
+	}
+
+	for _, tcase := range tableTest {
+		t.Run(tcase.name, func(t *testing.T) {
+			steps := planEvacuation(tcase.resources, "node1", tcase.filter)
+
+			var actual []expectedStep
+			for _, step := range steps {
+				actual = append(actual, expectedStep{
+					name:         step.replica.Name,
+					action:       step.action,
+					wanted:       step.wanted,
+					recordWanted: step.recordWanted,
+					additional:   step.additional,
+				})
+			}
+
+			if !reflect.DeepEqual(tcase.expected, actual) {
+				t.Errorf("expected: %+v, actual: %+v", tcase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPlanDetach(t *testing.T) {
+	replica := func(name, node, pool, diskState string, flags ...string) lapi.ResourceWithVolumes {
+		return lapi.ResourceWithVolumes{
+			Resource: lapi.Resource{Name: name, NodeName: node, Flags: flags},
+			Volumes: []lapi.Volume{
+				{StoragePoolName: pool, State: lapi.VolumeState{DiskState: diskState}},
+			},
+		}
+	}
+
+	resources := []lapi.ResourceWithVolumes{
+		replica("res1", "node1", "pool1", "Failed"),
+		replica("res1", "node2", "pool1", DiskStateUpToDate),
+		replica("res2", "node1", "pool1", "Failed"),
+		replica("res2", "node2", "pool1", "Outdated"),
+		replica("res2", "node3", "DfltDisklessStorPool", "Diskless", "DISKLESS"),
+		replica("res3", "node1", "pool2", DiskStateUpToDate),
+		replica("res3", "node2", "pool2", DiskStateUpToDate),
+		replica("res4", "node1", "DfltDisklessStorPool", "Diskless", "DISKLESS"),
+		replica("res4", "node2", "pool1", DiskStateUpToDate),
+	}
+
+	detach, blocked := planDetach(resources, "node1", "pool1")
+
+	if !reflect.DeepEqual([]string{"res1"}, detach) {
+		t.Errorf("expected: %v, actual: %v", []string{"res1"}, detach)
+	}
+
+	if !reflect.DeepEqual([]string{"res2"}, blocked) {
+		t.Errorf("expected: %v, actual: %v", []string{"res2"}, blocked)
+	}
+}
+
+func TestDefaultNetInterfaces(t *testing.T) {
+	addresses := []string{"192.168.1.5", "fd00::5", "10.30.0.5"}
+
+	testcases := []struct {
+		name      string
+		families  []shared.IPFamily
+		expected  []lapi.NetInterface
+		expectErr bool
+	}{
+		{
+			name: "primary",
+			expected: []lapi.NetInterface{
+				{Name: "default", Address: "192.168.1.5", IsActive: true, SatellitePort: 3366, SatelliteEncryptionType: "Plain"},
+			},
+		},
+		{
+			name:     "ipv6",
+			families: []shared.IPFamily{shared.IPv6},
+			expected: []lapi.NetInterface{
+				{Name: "default", Address: "fd00::5", IsActive: true, SatellitePort: 3366, SatelliteEncryptionType: "Plain"},
+			},
+		},
+		{
+			name:     "dual-stack",
+			families: []shared.IPFamily{shared.IPv6, shared.IPv4},
+			expected: []lapi.NetInterface{
+				{Name: "default", Address: "fd00::5", IsActive: true, SatellitePort: 3366, SatelliteEncryptionType: "Plain"},
+				{Name: "default-ipv4", Address: "192.168.1.5"},
+			},
+		},
+		{
+			name:      "duplicate-family",
+			families:  []shared.IPFamily{shared.IPv4, shared.IPv4},
+			expectErr: true,
+		},
+	}
+
+	for _, item := range testcases {
+		testCase := item
+		t.Run(testCase.name, func(t *testing.T) {
+			actual, err := DefaultNetInterfaces(addresses, testCase.families, nil)
+			if testCase.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got: %v", actual)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(testCase.expected, actual) {
+				t.Fatalf("expected: %v, actual: %v", testCase.expected, actual)
+			}
+		})
+	}
+
+	_, err := DefaultNetInterfaces([]string{"192.168.1.5"}, []shared.IPFamily{shared.IPv6}, nil)
+	if err == nil {
+		t.Errorf("expected error for missing IPv6 address")
+	}
+}
+
+func TestNetInterfaceUpToDate(t *testing.T) {
+	testcases := []struct {
+		name     string
+		existing lapi.NetInterface
+		wanted   lapi.NetInterface
+		expected bool
+	}{
+		{
+			name:     "same",
+			existing: lapi.NetInterface{Address: "10.0.0.1", SatellitePort: 3366, SatelliteEncryptionType: "Plain"},
+			wanted:   lapi.NetInterface{Address: "10.0.0.1", IsActive: true, SatellitePort: 3366, SatelliteEncryptionType: "Plain"},
+			expected: true,
+		},
+		{
+			name:     "ipv6-notation",
+			existing: lapi.NetInterface{Address: "fd00:0:0:0:0:0:0:5"},
+			wanted:   lapi.NetInterface{Address: "fd00::5"},
+			expected: true,
+		},
+		{
+			name:     "address-changed",
+			existing: lapi.NetInterface{Address: "10.0.0.1"},
+			wanted:   lapi.NetInterface{Address: "fd00::5"},
+			expected: false,
+		},
+		{
+			name:     "port-changed",
+			existing: lapi.NetInterface{Address: "10.0.0.1", SatellitePort: 3366, SatelliteEncryptionType: "Plain"},
+			wanted:   lapi.NetInterface{Address: "10.0.0.1", IsActive: true, SatellitePort: 3367, SatelliteEncryptionType: "SSL"},
+			expected: false,
+		},
+		{
+			name:     "port-ignored-for-inactive",
+			existing: lapi.NetInterface{Address: "10.0.0.1", SatellitePort: 3366},
+			wanted:   lapi.NetInterface{Address: "10.0.0.1"},
+			expected: true,
+		},
+	}
+
+	for _, item := range testcases {
+		testCase := item
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := netInterfaceUpToDate(testCase.existing, testCase.wanted)
+			if actual != testCase.expected {
+				t.Fatalf("expected: %t, actual: %t", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestUnwantedProps(t *testing.T) {
+	wanted := map[string]string{
+		"Aux/registered-by":                       "piraeus-operator",
+		"Aux/topology.kubernetes.io/zone":         "a",
+		kubeSpec.LinstorManagedPropertiesProperty: "Aux/topology.kubernetes.io/zone",
+	}
+
+	testcases := []struct {
+		name     string
+		existing map[string]string
+		wanted   map[string]string
+		expected []string
+	}{
+		{
+			name: "no-marker",
+			existing: map[string]string{
+				"Aux/registered-by":    "piraeus-operator",
+				"Aux/example.com/rack": "1",
+				"Aux/manual":           "yes",
+			},
+			wanted:   wanted,
+			expected: nil,
+		},
+		{
+			name: "only-managed",
+			existing: map[string]string{
+				"Aux/registered-by":                       "piraeus-operator",
+				"Aux/topology.kubernetes.io/zone":         "a",
+				"Aux/example.com/rack":                    "1",
+				"Aux/manual":                              "yes",
+				"PrefNic":                                 "default",
+				kubeSpec.LinstorManagedPropertiesProperty: "Aux/already-gone,Aux/example.com/rack,Aux/topology.kubernetes.io/zone,PrefNic",
+			},
+			wanted:   wanted,
+			expected: []string{"Aux/example.com/rack", "PrefNic"},
+		},
+		{
+			name: "marker-not-wanted",
+			existing: map[string]string{
+				"Aux/example.com/rack":                    "1",
+				kubeSpec.LinstorManagedPropertiesProperty: "Aux/example.com/rack",
+			},
+			wanted:   map[string]string{"Aux/registered-by": "piraeus-operator"},
+			expected: []string{"Aux/example.com/rack", kubeSpec.LinstorManagedPropertiesProperty},
+		},
+	}
+
+	for _, item := range testcases {
+		testCase := item
+		t.Run(testCase.name, func(t *testing.T) {
+			actual := unwantedProps(testCase.existing, testCase.wanted)
+			if !reflect.DeepEqual(testCase.expected, actual) {
+				t.Fatalf("expected: %v, actual: %v", testCase.expected, actual)
+			}
+		})
+	}
+}
+
+func TestGetNodeOrCreateRemovesInterfaces(t *testing.T) {
+	existingInterfaces := []lapi.NetInterface{
+		{Name: "default", Address: "10.0.0.1", IsActive: true},
+		{Name: "storage", Address: "192.168.0.1"},
+		{Name: "manual", Address: "192.168.1.1"},
+	}
+
+	testcases := []struct {
+		name             string
+		existingProps    map[string]string
+		expectedRequests []string
+	}{
+		{
+			name:          "created-by-operator",
+			existingProps: map[string]string{kubeSpec.LinstorManagedInterfacesProperty: "default,storage"},
+			expectedRequests: []string{
+				"PUT /v1/nodes/node-1",
+				"DELETE /v1/nodes/node-1/net-interfaces/storage",
+			},
+		},
+		{
+			name:             "legacy",
+			existingProps:    map[string]string{},
+			expectedRequests: []string{"PUT /v1/nodes/node-1"},
+		},
+	}
+
+	for _, item := range testcases {
+		testCase := item
+		t.Run(testCase.name, func(t *testing.T) {
+			var requests []string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					_ = json.NewEncoder(w).Encode(lapi.Node{
+						Name:          "node-1",
+						Props:         testCase.existingProps,
+						NetInterfaces: existingInterfaces,
+					})
+
+					return
+				}
+
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				_, _ = w.Write([]byte("[]"))
+			}))
+			defer server.Close()
+
+			c, err := NewHighLevelLinstorClientFromConfig(server.URL, &shared.LinstorClientConfig{}, nil)
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			_, err = c.GetNodeOrCreate(context.Background(), lapi.Node{
+				Name:          "node-1",
+				NetInterfaces: []lapi.NetInterface{existingInterfaces[0]},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(testCase.expectedRequests, requests) {
+				t.Errorf("expected requests %v, got %v", testCase.expectedRequests, requests)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	linstor "github.com/LINBIT/golinstor"
+	lapi "github.com/LINBIT/golinstor/client"
+
+	mdutil "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/metadata/util"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+// DiskStateUpToDate is the DRBD disk state of a fully synced replica.
+const DiskStateUpToDate = "UpToDate"
+
+// ReplicaFilter selects the replicas on a node that should be evacuated.
+type ReplicaFilter func(res *lapi.ResourceWithVolumes) bool
+
+// AllReplicas selects every replica on a node.
+func AllReplicas(*lapi.ResourceWithVolumes) bool {
+	return true
+}
+
+// InStoragePool selects replicas with at least one volume in the given storage pool.
+func InStoragePool(pool string) ReplicaFilter {
+	return func(res *lapi.ResourceWithVolumes) bool {
+		for i := range res.Volumes {
+			if res.Volumes[i].StoragePoolName == pool {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// EvacuationProgress reports the state of moving replicas away from a node or storage pool.
+type EvacuationProgress struct {
+	// Resources that still have a replica that needs to be moved.
+	Remaining []string
+	// Resources waiting for their replacement replicas to finish syncing.
+	Syncing []string
+}
+
+// Done returns true if no replica needs to be moved anymore.
+func (p *EvacuationProgress) Done() bool {
+	return len(p.Remaining) == 0
+}
+
+type evacuationAction string
+
+const (
+	// Place additional replicas on other nodes
+	evacuationPlace evacuationAction = "place"
+	// Wait for replicas on other nodes to be UpToDate
+	evacuationSync evacuationAction = "sync"
+	// Remove the evacuated replica
+	evacuationRemove evacuationAction = "remove"
+)
+
+type evacuationStep struct {
+	replica *lapi.ResourceWithVolumes
+	action  evacuationAction
+	// Number of diskful replicas expected on other nodes, before the replica can be removed.
+	wanted int
+	// Set if the wanted replica count is not yet recorded on the replica.
+	recordWanted bool
+	// Number of replicas to place.
+	additional int32
+}
+
+// Evacuate moves the selected diskful replicas on the node to other nodes.
+//
+// For every replica, a new replica is placed on another node, avoiding the given storage pools. Once all other
+// replicas are UpToDate, the evacuated replica is removed. Replicas that are in use are converted to diskless
+// instead, so that running applications are not interrupted. The number of replicas to keep is recorded on the
+// evacuated replica itself, so the evacuation can be continued across reconciliations.
+//
+// The function does not block. Instead, it returns the current progress and should be called again until it
+// reports the evacuation as done.
+func (c *HighLevelClient) Evacuate(ctx context.Context, nodeName string, filter ReplicaFilter, avoidPools ...string) (*EvacuationProgress, error) {
+	resources, err := c.Resources.GetResourceView(ctx)
+	if err != nil && err != lapi.NotFoundError {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+
+	steps := planEvacuation(resources, nodeName, filter)
+
+	var poolList []string
+
+	if len(avoidPools) != 0 && len(steps) != 0 {
+		poolList, err = c.storagePoolsExcept(ctx, avoidPools)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	progress := &EvacuationProgress{}
+
+	for _, step := range steps {
+		name := step.replica.Name
+
+		progress.Remaining = append(progress.Remaining, name)
+
+		if step.recordWanted {
+			err := c.Resources.Modify(ctx, name, nodeName, lapi.GenericPropsModify{
+				OverrideProps: map[string]string{kubeSpec.LinstorEvacuationProperty: strconv.Itoa(step.wanted)},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to mark resource '%s' on node '%s' for evacuation: %w", name, nodeName, err)
+			}
+		}
+
+		switch step.action {
+		case evacuationPlace:
+			err := c.Resources.Autoplace(ctx, name, lapi.AutoPlaceRequest{
+				SelectFilter: lapi.AutoSelectFilter{
+					AdditionalPlaceCount: step.additional,
+					StoragePoolList:      poolList,
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to place replacement replica for resource '%s': %w", name, err)
+			}
+
+			progress.Syncing = append(progress.Syncing, name)
+		case evacuationSync:
+			progress.Syncing = append(progress.Syncing, name)
+		case evacuationRemove:
+			if !step.replica.State.InUse {
+				err := c.Resources.Delete(ctx, name, nodeName)
+				if err != nil {
+					return nil, fmt.Errorf("failed to remove evacuated replica of resource '%s' on node '%s': %w", name, nodeName, err)
+				}
+
+				continue
+			}
+
+			err := c.Resources.Diskless(ctx, name, nodeName, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert evacuated replica of resource '%s' on node '%s' to diskless: %w", name, nodeName, err)
+			}
+
+			err = c.Resources.Modify(ctx, name, nodeName, lapi.GenericPropsModify{
+				DeleteProps: []string{kubeSpec.LinstorEvacuationProperty},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to remove evacuation mark from resource '%s' on node '%s': %w", name, nodeName, err)
+			}
+		}
+	}
+
+	return progress, nil
+}
+
+// storagePoolsExcept returns the names of all diskful storage pools in the cluster, except the given ones.
+func (c *HighLevelClient) storagePoolsExcept(ctx context.Context, except []string) ([]string, error) {
+	pools, err := c.Nodes.GetStoragePoolView(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage pools: %w", err)
+	}
+
+	exclude := make(map[string]struct{}, len(except))
+	for _, p := range except {
+		exclude[p] = struct{}{}
+	}
+
+	names := make(map[string]struct{})
+
+	for i := range pools {
+		if pools[i].ProviderKind == lapi.DISKLESS {
+			continue
+		}
+
+		if _, ok := exclude[pools[i].StoragePoolName]; ok {
+			continue
+		}
+
+		names[pools[i].StoragePoolName] = struct{}{}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no storage pool available for replacement replicas")
+	}
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
+
+// planEvacuation determines the next step for every selected replica on the node.
+func planEvacuation(resources []lapi.ResourceWithVolumes, nodeName string, filter ReplicaFilter) []evacuationStep {
+	byName := make(map[string][]*lapi.ResourceWithVolumes)
+	for i := range resources {
+		byName[resources[i].Name] = append(byName[resources[i].Name], &resources[i])
+	}
+
+	var steps []evacuationStep
+
+	for i := range resources {
+		replica := &resources[i]
+
+		if replica.NodeName != nodeName || !IsDiskful(replica) || mdutil.SliceContains(replica.Flags, linstor.FlagDelete) || !filter(replica) {
+			continue
+		}
+
+		var others []*lapi.ResourceWithVolumes
+
+		for _, other := range byName[replica.Name] {
+			if other.NodeName != nodeName && IsDiskful(other) && !mdutil.SliceContains(other.Flags, linstor.FlagDelete) {
+				others = append(others, other)
+			}
+		}
+
+		step := evacuationStep{replica: replica}
+
+		wanted, err := strconv.Atoi(replica.Props[kubeSpec.LinstorEvacuationProperty])
+		if err != nil || wanted <= 0 {
+			// Keep the current number of diskful replicas once this replica is gone.
+			wanted = len(others) + 1
+			step.recordWanted = true
+		}
+
+		step.wanted = wanted
+
+		switch {
+		case len(others) < wanted:
+			step.action = evacuationPlace
+			step.additional = int32(wanted - len(others))
+		case !allUpToDate(others):
+			step.action = evacuationSync
+		default:
+			step.action = evacuationRemove
+		}
+
+		steps = append(steps, step)
+	}
+
+	sort.Slice(steps, func(i, j int) bool {
+		return steps[i].replica.Name < steps[j].replica.Name
+	})
+
+	return steps
+}
+
+// IsDiskful returns true if the replica stores data locally.
+func IsDiskful(res *lapi.ResourceWithVolumes) bool {
+	return !mdutil.SliceContains(res.Flags, linstor.FlagDiskless)
+}
+
+func allUpToDate(replicas []*lapi.ResourceWithVolumes) bool {
+	for _, r := range replicas {
+		if len(r.Volumes) == 0 {
+			return false
+		}
+
+		for i := range r.Volumes {
+			if r.Volumes[i].State.DiskState != DiskStateUpToDate {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	linstor "github.com/LINBIT/golinstor"
+	lapi "github.com/LINBIT/golinstor/client"
+
+	mdutil "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/metadata/util"
+	kubeSpec "github.com/piraeusdatastore/piraeus-operator/pkg/k8s/spec"
+)
+
+// DetachProgress reports the state of removing the replicas from a storage pool that is about to be rebuilt.
+type DetachProgress struct {
+	// Resources that still had a diskful replica in the storage pool. They were converted to diskless.
+	Remaining []string
+	// Resources without an UpToDate replica on another node. They can't be converted without losing data.
+	Blocked []string
+}
+
+// Done returns true if the storage pool no longer contains any replicas.
+func (p *DetachProgress) Done() bool {
+	return len(p.Remaining) == 0 && len(p.Blocked) == 0
+}
+
+// DetachFromStoragePool converts the diskful replicas in the storage pool on the node to diskless.
+//
+// Only replicas with an UpToDate replica on another node are converted, so no data is lost. The storage pool is
+// recorded on the converted replica, so ReattachReplicas can restore it once the pool is rebuilt.
+//
+// The function does not block. Instead, it returns the current progress and should be called again until it
+// reports the storage pool as empty.
+func (c *HighLevelClient) DetachFromStoragePool(ctx context.Context, nodeName, pool string) (*DetachProgress, error) {
+	resources, err := c.Resources.GetResourceView(ctx)
+	if err != nil && err != lapi.NotFoundError {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+
+	detach, blocked := planDetach(resources, nodeName, pool)
+
+	progress := &DetachProgress{Blocked: blocked}
+
+	for _, name := range detach {
+		progress.Remaining = append(progress.Remaining, name)
+
+		err := c.Resources.Modify(ctx, name, nodeName, lapi.GenericPropsModify{
+			OverrideProps: map[string]string{kubeSpec.LinstorReplacedPoolProperty: pool},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark resource '%s' on node '%s' for replacement: %w", name, nodeName, err)
+		}
+
+		err = c.Resources.Diskless(ctx, name, nodeName, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert replica of resource '%s' on node '%s' to diskless: %w", name, nodeName, err)
+		}
+	}
+
+	return progress, nil
+}
+
+// ReattachReplicas converts replicas previously detached from one of the storage pools back to diskful. DRBD then
+// syncs the data from the other replicas. Returns the names of the reattached resources.
+func (c *HighLevelClient) ReattachReplicas(ctx context.Context, nodeName string, pools ...string) ([]string, error) {
+	resources, err := c.GetAllResourcesOnNode(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources on node '%s': %w", nodeName, err)
+	}
+
+	var reattached []string
+
+	for i := range resources {
+		res := &resources[i]
+
+		pool, ok := res.Props[kubeSpec.LinstorReplacedPoolProperty]
+		if !ok || !mdutil.SliceContains(pools, pool) {
+			continue
+		}
+
+		if !IsDiskful(res) {
+			err := c.Resources.Diskful(ctx, res.Name, nodeName, pool, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert replica of resource '%s' on node '%s' to diskful: %w", res.Name, nodeName, err)
+			}
+
+			reattached = append(reattached, res.Name)
+		}
+
+		err := c.Resources.Modify(ctx, res.Name, nodeName, lapi.GenericPropsModify{
+			DeleteProps: []string{kubeSpec.LinstorReplacedPoolProperty},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove replacement mark from resource '%s' on node '%s': %w", res.Name, nodeName, err)
+		}
+	}
+
+	sort.Strings(reattached)
+
+	return reattached, nil
+}
+
+// planDetach returns the resources with a diskful replica in the pool on the node that can be converted to diskless,
+// and those that have no UpToDate replica on another node.
+func planDetach(resources []lapi.ResourceWithVolumes, nodeName, pool string) ([]string, []string) {
+	byName := make(map[string][]*lapi.ResourceWithVolumes)
+	for i := range resources {
+		byName[resources[i].Name] = append(byName[resources[i].Name], &resources[i])
+	}
+
+	inPool := InStoragePool(pool)
+
+	var detach, blocked []string
+
+	for i := range resources {
+		replica := &resources[i]
+
+		if replica.NodeName != nodeName || !IsDiskful(replica) || mdutil.SliceContains(replica.Flags, linstor.FlagDelete) || !inPool(replica) {
+			continue
+		}
+
+		healthyPeer := false
+
+		for _, other := range byName[replica.Name] {
+			if other.NodeName != nodeName && IsDiskful(other) && !mdutil.SliceContains(other.Flags, linstor.FlagDelete) && allUpToDate([]*lapi.ResourceWithVolumes{other}) {
+				healthyPeer = true
+				break
+			}
+		}
+
+		if healthyPeer {
+			detach = append(detach, replica.Name)
+		} else {
+			blocked = append(blocked, replica.Name)
+		}
+	}
+
+	sort.Strings(detach)
+	sort.Strings(blocked)
+
+	return detach, blocked
+}